@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"apiSorteos/internal/models"
+)
+
+// TestAdminActionsAreAudited recorre login, giro y anulación con una
+// X-Forwarded-For falsa: cada acción queda en la bitácora con la IP real.
+func TestAdminActionsAreAudited(t *testing.T) {
+	srv, auditLog := newContractServer(t)
+
+	do := func(method, path, token, body string) []byte {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var raw json.RawMessage
+		_ = json.NewDecoder(resp.Body).Decode(&raw)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s %s = %d: %s", method, path, resp.StatusCode, raw)
+		}
+		return raw
+	}
+
+	var login struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(do(http.MethodPost, "/api/v1/auth/login", "", `{"username":"admin","password":"navidad2024"}`), &login); err != nil {
+		t.Fatal(err)
+	}
+	var record models.WinnerRecord
+	if err := json.Unmarshal(do(http.MethodPost, "/api/v1/spin", login.Token, `{"participantId":1,"prizeId":1}`), &record); err != nil {
+		t.Fatal(err)
+	}
+	do(http.MethodPost, "/api/v1/winners/"+strconv.Itoa(record.ID)+"/void", login.Token, `{"reason":"la persona no estaba presente"}`)
+
+	for _, action := range []string{"auth.login", "raffle.spin", "award.void"} {
+		entries, err := auditLog.List(context.Background(), models.AuditFilter{Action: action, Limit: 1})
+		if err != nil || len(entries) == 0 {
+			t.Fatalf("no se registró %s: %v", action, err)
+		}
+		if entry := entries[0]; entry.Actor != "admin" || entry.IP != "127.0.0.1" {
+			t.Errorf("%s: actor %q desde %q, se esperaba admin desde 127.0.0.1", action, entry.Actor, entry.IP)
+		}
+	}
+}
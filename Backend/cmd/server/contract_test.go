@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"apiSorteos/internal/audit"
+	"apiSorteos/internal/eventbus"
+	"apiSorteos/internal/handlers"
+	"apiSorteos/internal/health"
+	"apiSorteos/internal/metrics"
+	"apiSorteos/internal/middleware"
+	"apiSorteos/internal/openapi"
+	"apiSorteos/internal/raffle"
+	"apiSorteos/internal/repository"
+	"apiSorteos/internal/webhooks"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gin-gonic/gin"
+)
+
+// TestContract comprueba que las respuestas reales de login, state, spin y
+// el primer mensaje de /events cumplen el openapi.json embebido.
+func TestContract(t *testing.T) {
+	srv, _ := newContractServer(t)
+	doc, router := loadSpec(t, srv.URL)
+
+	token := ""
+	t.Run("login", func(t *testing.T) {
+		body := checkResponse(t, router, srv, http.MethodPost, "/api/v1/auth/login", "", `{"username":"admin","password":"navidad2024"}`, http.StatusOK)
+		var payload struct {
+			Token string `json:"token"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil || payload.Token == "" {
+			t.Fatalf("login sin token: %s", body)
+		}
+		token = payload.Token
+	})
+	t.Run("login fallido", func(t *testing.T) {
+		checkResponse(t, router, srv, http.MethodPost, "/api/v1/auth/login", "", `{"username":"admin","password":"otra"}`, http.StatusUnauthorized)
+	})
+	t.Run("state", func(t *testing.T) {
+		checkResponse(t, router, srv, http.MethodGet, "/api/v1/state", "", "", http.StatusOK)
+	})
+	t.Run("spin", func(t *testing.T) {
+		checkResponse(t, router, srv, http.MethodPost, "/api/v1/spin", token, `{"participantId":1,"prizeId":1}`, http.StatusOK)
+	})
+	t.Run("spin repetido", func(t *testing.T) {
+		checkResponse(t, router, srv, http.MethodPost, "/api/v1/spin", token, `{"participantId":1,"prizeId":2}`, http.StatusConflict)
+	})
+	t.Run("spin con cuerpo inválido", func(t *testing.T) {
+		checkResponse(t, router, srv, http.MethodPost, "/api/v1/spin", token, `{"participantId":`, http.StatusBadRequest)
+	})
+	t.Run("spin sin cuerpo", func(t *testing.T) {
+		checkResponse(t, router, srv, http.MethodPost, "/api/v1/spin", token, "", http.StatusConflict)
+	})
+	t.Run("spin sin sesión", func(t *testing.T) {
+		checkResponse(t, router, srv, http.MethodPost, "/api/v1/spin", "", `{"participantId":2,"prizeId":2}`, http.StatusUnauthorized)
+	})
+	t.Run("events", func(t *testing.T) {
+		checkFirstEvent(t, doc, router, srv)
+	})
+}
+
+// loadSpec carga el contrato embebido; el servidor "/" se reemplaza por la
+// URL de prueba para que el router encuentre las operaciones.
+func loadSpec(t *testing.T, serverURL string) (*openapi3.T, routers.Router) {
+	t.Helper()
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(openapi.Spec)
+	if err != nil {
+		t.Fatalf("openapi.json no se pudo leer: %v", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		t.Fatalf("openapi.json no es válido: %v", err)
+	}
+	doc.Servers = openapi3.Servers{{URL: serverURL}}
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		t.Fatalf("no se pudo crear el router del contrato: %v", err)
+	}
+	return doc, router
+}
+
+// newContractServer arma las rutas igual que main, sin proxies de confianza.
+func newContractServer(t *testing.T) (*httptest.Server, *audit.Logger) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	repo := repository.NewInMemoryRepository()
+	signer, err := raffle.NewSigner("Sorteo de prueba", bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	service, err := raffle.NewService(repo, signer, eventbus.NewInProcess())
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := raffle.NewAuthService(raffle.AuthConfig{
+		Users:  map[string]string{"admin": "navidad2024"},
+		Secret: []byte("secreto-de-prueba"),
+		TTL:    sessionTTL(),
+	}, repo)
+	auditLog := audit.NewLogger(repo)
+	checker := health.NewChecker(health.Backend{Name: "inmemory"}, repo, false, service.ClientCount, version)
+	apiHandler := handlers.NewAPIHandler(service, auth, auditLog, webhooks.NewDispatcher(repo), checker, metrics.New())
+
+	engine := gin.New()
+	if err := engine.SetTrustedProxies(nil); err != nil {
+		t.Fatal(err)
+	}
+	engine.Use(middleware.RequestID(), middleware.Language("es"), middleware.Errors())
+	v1 := engine.Group("/api/v1", middleware.APIVersion(middleware.CurrentAPIVersion))
+	registerAPI(v1, apiHandler, auth, auditLog)
+	v1.GET("/events", apiHandler.StreamEvents)
+
+	srv := httptest.NewServer(engine)
+	t.Cleanup(srv.Close)
+	return srv, auditLog
+}
+
+func checkResponse(t *testing.T, router routers.Router, srv *httptest.Server, method, path, token, body string, status int) []byte {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != status {
+		t.Fatalf("%s %s = %d, se esperaba %d: %s", method, path, resp.StatusCode, status, respBody)
+	}
+
+	validate(t, router, req, resp, respBody, false)
+	return respBody
+}
+
+// checkFirstEvent valida la respuesta de /events y el primer mensaje contra
+// el esquema que x-sse-events declara para su tipo.
+func checkFirstEvent(t *testing.T, doc *openapi3.T, router routers.Router, srv *httptest.Server) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/v1/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /api/v1/events = %d", resp.StatusCode)
+	}
+	// El validador no decodifica text/event-stream; el tipo se comprueba aquí
+	// y cada mensaje contra su esquema.
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Fatalf("Content-Type = %q, se esperaba text/event-stream", ct)
+	}
+	validate(t, router, req, resp, nil, true)
+
+	name, data := readEvent(t, resp.Body)
+	schemas, ok := doc.Paths.Find("/api/v1/events").Get.Extensions["x-sse-events"].(map[string]any)
+	if !ok {
+		t.Fatal("openapi.json no declara x-sse-events")
+	}
+	ref, ok := schemas[name].(map[string]any)
+	if !ok {
+		t.Fatalf("el evento %q no está en x-sse-events", name)
+	}
+	schemaName := strings.TrimPrefix(ref["$ref"].(string), "#/components/schemas/")
+	schema := doc.Components.Schemas[schemaName]
+	if schema == nil {
+		t.Fatalf("x-sse-events apunta a un esquema inexistente: %s", schemaName)
+	}
+
+	var value any
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		t.Fatalf("el evento %q no trae JSON: %v", name, err)
+	}
+	if err := schema.Value.VisitJSON(value); err != nil {
+		t.Errorf("el evento %q no cumple %s: %v", name, schemaName, err)
+	}
+}
+
+func readEvent(t *testing.T, r io.Reader) (name, data string) {
+	t.Helper()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		case line == "" && name != "":
+			return name, data
+		}
+	}
+	t.Fatalf("el flujo terminó sin eventos: %v", scanner.Err())
+	return "", ""
+}
+
+func validate(t *testing.T, router routers.Router, req *http.Request, resp *http.Response, body []byte, excludeBody bool) {
+	t.Helper()
+	route, pathParams, err := router.FindRoute(req)
+	if err != nil {
+		t.Fatalf("%s %s no está en el contrato: %v", req.Method, req.URL.Path, err)
+	}
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+		},
+		Status: resp.StatusCode,
+		Header: resp.Header,
+		Options: &openapi3filter.Options{
+			ExcludeResponseBody:   excludeBody,
+			IncludeResponseStatus: true,
+		},
+	}
+	if !excludeBody {
+		input.SetBodyBytes(body)
+	}
+	if err := openapi3filter.ValidateResponse(req.Context(), input); err != nil {
+		t.Errorf("%s %s no cumple el contrato: %v", req.Method, req.URL.Path, err)
+	}
+}
@@ -1,57 +1,129 @@
 package main
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
 	"database/sql"
+	"encoding/base64"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	"apiSorteos/internal/audit"
+	"apiSorteos/internal/certs"
+	"apiSorteos/internal/config"
+	"apiSorteos/internal/eventbus"
 	"apiSorteos/internal/handlers"
+	"apiSorteos/internal/health"
+	"apiSorteos/internal/i18n"
+	"apiSorteos/internal/logging"
+	"apiSorteos/internal/metrics"
+	"apiSorteos/internal/middleware"
 	"apiSorteos/internal/raffle"
+	"apiSorteos/internal/ratelimit"
 	"apiSorteos/internal/repository"
+	"apiSorteos/internal/webhooks"
+	"apiSorteos/internal/webui"
 
 	"github.com/gin-gonic/gin"
 )
 
+// version se fija al compilar con -ldflags "-X main.version=..."
+var version = "dev"
+
 func main() {
-	adminPassword := os.Getenv("ADMIN_PASSWORD")
-	if adminPassword == "" {
-		adminPassword = "navidad2024"
+	if path := os.Getenv("CONFIG_PATH"); path != "" {
+		if err := config.LoadConfig(path); err != nil {
+			fatal("No se pudo leer la configuración", "path", path, "error", err)
+		}
 	}
+	logs, err := setupLogging()
+	if err != nil {
+		fatal("No se pudo configurar el log", "error", err)
+	}
+	defer logs.Close()
 
-	repo, cleanup, err := buildRepository()
+	policy := fallbackPolicy()
+	repo, backend, cleanup, err := buildRepository(policy)
 	if err != nil {
-		log.Fatalf("No se pudo inicializar el repositorio: %v", err)
+		fatal("No se pudo inicializar el repositorio", "error", err)
 	}
 	defer cleanup()
-	service := raffle.NewService(repo)
-	auth := raffle.NewAuthService(adminPassword)
-
-	router := gin.Default()
-	router.Use(func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization")
-		if c.Request.Method == http.MethodOptions {
-			c.AbortWithStatus(http.StatusNoContent)
-			return
-		}
-		c.Next()
-	})
-
-	apiHandler := handlers.NewAPIHandler(service, auth)
+	stats := metrics.New()
+	repo = metrics.InstrumentStore(repo, stats)
+	signer, err := loadSigner()
+	if err != nil {
+		fatal("No se pudo cargar la clave de firma", "error", err)
+	}
+	bus, err := buildEventBus()
+	if err != nil {
+		fatal("No se pudo inicializar el bus de eventos", "error", err)
+	}
+	defer bus.Close()
+	service, err := raffle.NewService(repo, signer, bus)
+	if err != nil {
+		fatal("No se pudo inicializar el servicio de sorteos", "error", err)
+	}
+	service.SetStateSample(stateSample())
+	stats.WatchService(service)
+	auth := raffle.NewAuthService(raffle.AuthConfig{
+		Users:  adminUsers(),
+		Secret: authSecret(),
+		TTL:    sessionTTL(),
+		Issuer: getenv("TOTP_ISSUER", "Sorteos Fundasen"),
+	}, repo)
 
-	api := router.Group("/api")
-	{
-		api.POST("/auth/login", apiHandler.Login)
-		api.GET("/state", apiHandler.GetState)
-		api.POST("/spin", apiHandler.AdminOnly(apiHandler.RegisterSpin))
+	router := gin.New()
+	if err := router.SetTrustedProxies(trustedProxies()); err != nil {
+		fatal("Lista de proxies de confianza inválida", "error", err)
 	}
+	router.Use(gin.Recovery(), middleware.RequestID(), middleware.Language(raffleLanguage()), middleware.AccessLog(), stats.Middleware(), middleware.Errors())
+	limits := requestLimits()
+	cors := corsOptions()
+	router.Use(middleware.CORS(cors), middleware.RateLimit(limits.perIP, limits.routes), middleware.BodyLimit(limits.maxBody, limits.bodyRoutes))
+	streams := middleware.LimitStreams(limits.streams)
+
+	auditLog := audit.NewLogger(repo)
+	dispatcher := webhooks.NewDispatcher(repo)
+	service.OnPublish(dispatcher.Notify)
+	checker := health.NewChecker(backend, repo, policy == fallbackNotReady, service.ClientCount, version)
+	apiHandler := handlers.NewAPIHandler(service, auth, auditLog, dispatcher, checker, stats)
+	apiHandler.SetCORS(cors)
+
+	v1 := router.Group("/api/v1", middleware.APIVersion(middleware.CurrentAPIVersion))
+	registerAPI(v1, apiHandler, auth, auditLog)
+	v1.GET("/events", streams, apiHandler.StreamEvents)
+	v1.GET("/ws", streams, apiHandler.WebSocket)
 
-	router.GET("/events", apiHandler.StreamEvents)
+	// Las rutas sin versión atienden el contrato v1 hasta la fecha de retiro.
+	sunset := legacySunset()
+	legacy := router.Group("/api", middleware.Deprecated("/api", "/api/v1", sunset), middleware.APIVersion(middleware.CurrentAPIVersion))
+	registerAPI(legacy, apiHandler, auth, auditLog)
+	legacyStream := middleware.Deprecated("", "/api/v1", sunset)
+	router.GET("/events", legacyStream, streams, apiHandler.StreamEvents)
+	router.GET("/ws", legacyStream, streams, apiHandler.WebSocket)
+
+	router.GET("/healthz", apiHandler.Healthz)
+	router.GET("/metrics", gin.WrapH(stats.Handler()))
+	router.GET("/readyz", apiHandler.Readyz)
+
+	// Con -tags embedui el mismo binario sirve también la interfaz React.
+	if assets, ok := webui.Assets(); ok {
+		router.NoRoute(webui.Handler(assets))
+		slog.Info("Sirviendo la interfaz web embebida")
+	}
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -62,38 +134,539 @@ func main() {
 		Addr:    ":" + port,
 		Handler: router,
 	}
+	tlsOpts, err := setupTLS()
+	if err != nil {
+		fatal("No se pudo preparar el certificado TLS", "error", err)
+	}
 
-	log.Printf("Servidor escuchando en el puerto %s", port)
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("No se pudo iniciar el servidor: %v", err)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		var err error
+		if tlsOpts.enabled() {
+			srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+			slog.Info("Servidor escuchando con HTTPS", "port", port)
+			err = srv.ListenAndServeTLS(tlsOpts.certFile, tlsOpts.keyFile)
+		} else {
+			slog.Info("Servidor escuchando", "port", port)
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			fatal("No se pudo iniciar el servidor", "error", err)
+		}
+	}()
+
+	var redirect *http.Server
+	if tlsOpts.enabled() && tlsOpts.redirectPort != "" {
+		redirect = &http.Server{
+			Addr:              ":" + tlsOpts.redirectPort,
+			Handler:           certs.RedirectHandler(port),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		go func() {
+			slog.Info("Redirigiendo HTTP a HTTPS", "port", tlsOpts.redirectPort)
+			if err := redirect.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fatal("No se pudo iniciar la redirección a HTTPS", "error", err)
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	stop()
+	slog.Info("Apagando el servidor, esperando giros en curso")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout())
+	defer cancel()
+	// Primero el servicio: cierra los streams SSE y WebSocket, que de otro
+	// modo mantendrían ocupado a srv.Shutdown hasta el límite de tiempo.
+	if err := service.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Quedaron giros sin terminar al apagar", "error", err)
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("No se pudieron cerrar todas las conexiones", "error", err)
+	}
+	if err := dispatcher.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Quedaron webhooks sin entregar al apagar", "error", err)
 	}
+	if redirect != nil {
+		_ = redirect.Shutdown(shutdownCtx)
+	}
+	slog.Info("Servidor detenido")
+}
+
+type tlsOptions struct {
+	certFile     string
+	keyFile      string
+	redirectPort string
+}
+
+func (o tlsOptions) enabled() bool {
+	return o.certFile != "" && o.keyFile != ""
 }
 
-func buildRepository() (repository.Repository, func(), error) {
+// setupTLS toma la sección "server.tls" de CONFIG_PATH; TLS_CERT_FILE,
+// TLS_KEY_FILE, TLS_SELF_SIGNED, TLS_HOSTS y HTTP_REDIRECT_PORT tienen
+// prioridad. Sin certificado el servidor atiende HTTP como antes.
+func setupTLS() (tlsOptions, error) {
+	cfg := config.Configs().Server.TLS
+	opts := tlsOptions{
+		certFile: getenv("TLS_CERT_FILE", cfg.CertFile),
+		keyFile:  getenv("TLS_KEY_FILE", cfg.KeyFile),
+	}
+	if cfg.RedirectPort > 0 {
+		opts.redirectPort = strconv.Itoa(cfg.RedirectPort)
+	}
+	opts.redirectPort = getenv("HTTP_REDIRECT_PORT", opts.redirectPort)
+
+	selfSigned := cfg.SelfSigned
+	if b, err := strconv.ParseBool(os.Getenv("TLS_SELF_SIGNED")); err == nil {
+		selfSigned = b
+	}
+	if !selfSigned {
+		return opts, nil
+	}
+
+	if opts.certFile == "" {
+		opts.certFile = filepath.Join("tls", "cert.pem")
+	}
+	if opts.keyFile == "" {
+		opts.keyFile = filepath.Join("tls", "key.pem")
+	}
+	hosts := envList("TLS_HOSTS", cfg.Hosts)
+	if len(hosts) == 0 {
+		hosts = certs.LocalHosts()
+	}
+	created, err := certs.EnsureSelfSigned(opts.certFile, opts.keyFile, hosts)
+	if err != nil {
+		return opts, err
+	}
+	fingerprint, err := certs.Fingerprint(opts.certFile)
+	if err != nil {
+		return opts, err
+	}
+	if created {
+		slog.Info("Se generó un certificado autofirmado", "path", opts.certFile, "hosts", hosts, "sha256", fingerprint)
+	} else {
+		slog.Info("Usando el certificado autofirmado existente", "path", opts.certFile, "sha256", fingerprint)
+	}
+	return opts, nil
+}
+
+func shutdownTimeout() time.Duration {
+	seconds, err := strconv.Atoi(getenv("SHUTDOWN_TIMEOUT_SECONDS", "20"))
+	if err != nil || seconds <= 0 {
+		slog.Warn("SHUTDOWN_TIMEOUT_SECONDS inválido, usando 20 segundos")
+		seconds = 20
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// setupLogging toma la sección "logs" de CONFIG_PATH; LOG_PATH, LOG_LEVEL,
+// LOG_MAX_SIZE_MB y LOG_MAX_BACKUPS tienen prioridad sobre el archivo.
+func setupLogging() (io.Closer, error) {
+	cfg := config.Configs().Logs
+	opts := logging.Options{
+		Level:      getenv("LOG_LEVEL", cfg.Level),
+		Path:       getenv("LOG_PATH", cfg.Path),
+		MaxSizeMB:  cfg.MaxSizeMB,
+		MaxBackups: cfg.MaxBackups,
+	}
+	if n, err := strconv.Atoi(os.Getenv("LOG_MAX_SIZE_MB")); err == nil {
+		opts.MaxSizeMB = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("LOG_MAX_BACKUPS")); err == nil {
+		opts.MaxBackups = n
+	}
+	if opts.MaxSizeMB <= 0 {
+		opts.MaxSizeMB = 100
+	}
+	_, closer, err := logging.Setup(opts)
+	return closer, err
+}
+
+// trustedProxies toma server.trustedProxies de CONFIG_PATH o TRUSTED_PROXIES
+// (lista separada por comas). Sin proxies la IP del cliente es la del socket,
+// así X-Forwarded-For no sirve para esquivar los límites ni la auditoría.
+func trustedProxies() []string {
+	return envList("TRUSTED_PROXIES", config.Configs().Server.TrustedProxies)
+}
+
+// corsOptions toma la sección "cors" de CONFIG_PATH; CORS_ALLOWED_ORIGINS,
+// CORS_ALLOWED_METHODS, CORS_ALLOWED_HEADERS (listas separadas por comas),
+// CORS_ALLOW_CREDENTIALS y CORS_MAX_AGE_SECONDS tienen prioridad. Sin orígenes
+// configurados solo se atiende al mismo origen; "*" debe pedirse explícitamente.
+func corsOptions() middleware.CORSOptions {
+	cfg := config.Configs().Cors
+	opts := middleware.CORSOptions{
+		AllowedOrigins:   envList("CORS_ALLOWED_ORIGINS", cfg.AllowedOrigins),
+		AllowedMethods:   envList("CORS_ALLOWED_METHODS", cfg.AllowedMethods),
+		AllowedHeaders:   envList("CORS_ALLOWED_HEADERS", cfg.AllowedHeaders),
+		ExposedHeaders:   []string{middleware.RequestIDHeader, middleware.APIVersionHeader, "Deprecation", "Sunset", "Link"},
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           time.Duration(cfg.MaxAgeSeconds) * time.Second,
+	}
+	if b, err := strconv.ParseBool(os.Getenv("CORS_ALLOW_CREDENTIALS")); err == nil {
+		opts.AllowCredentials = b
+	}
+	if n, err := strconv.Atoi(os.Getenv("CORS_MAX_AGE_SECONDS")); err == nil {
+		opts.MaxAge = time.Duration(n) * time.Second
+	}
+	if len(opts.AllowedMethods) == 0 {
+		opts.AllowedMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions}
+	}
+	if len(opts.AllowedHeaders) == 0 {
+		opts.AllowedHeaders = []string{"Origin", "Content-Type", "Authorization", "Accept-Language", "Last-Event-ID", middleware.APIVersionHeader, middleware.RequestIDHeader}
+	}
+	if opts.MaxAge == 0 {
+		opts.MaxAge = 10 * time.Minute
+	}
+	switch {
+	case len(opts.AllowedOrigins) == 0:
+		slog.Info("CORS sin orígenes permitidos: solo se aceptan navegadores del mismo origen")
+	case slices.Contains(opts.AllowedOrigins, "*"):
+		slog.Warn("CORS acepta cualquier origen; limita CORS_ALLOWED_ORIGINS en producción")
+	}
+	return opts
+}
+
+type limitOptions struct {
+	perIP      *ratelimit.Limiter
+	routes     map[string]*ratelimit.Limiter
+	streams    *ratelimit.Counter
+	maxBody    int64
+	bodyRoutes map[string]int64
+}
+
+// defaultRouteRates protege las rutas más costosas: /state hace tres
+// consultas al repositorio y el login es blanco de fuerza bruta.
+var defaultRouteRates = map[string]config.RateRule{
+	"GET /state":        {RequestsPerSecond: 2, Burst: 10},
+	"GET /participants": {RequestsPerSecond: 5, Burst: 20},
+	"POST /auth/login":  {RequestsPerSecond: 0.2, Burst: 5},
+}
+
+// requestLimits toma la sección "limits" de CONFIG_PATH; RATE_LIMIT_RPS,
+// RATE_LIMIT_BURST, RATE_LIMIT_ROUTES ("GET /state=2:10,POST /auth/login=0.2:5"),
+// MAX_STREAMS_PER_IP, MAX_BODY_BYTES y MAX_UPLOAD_BYTES tienen prioridad.
+// Un valor 0 desactiva el límite correspondiente.
+func requestLimits() limitOptions {
+	cfg := config.Configs().Limits
+	perIP := config.RateRule{RequestsPerSecond: 20, Burst: 40}
+	if cfg.RequestsPerSecond != 0 || cfg.Burst != 0 {
+		perIP = config.RateRule{RequestsPerSecond: cfg.RequestsPerSecond, Burst: cfg.Burst}
+	}
+	if n, err := strconv.ParseFloat(os.Getenv("RATE_LIMIT_RPS"), 64); err == nil {
+		perIP.RequestsPerSecond = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("RATE_LIMIT_BURST")); err == nil {
+		perIP.Burst = n
+	}
+
+	routeRates := defaultRouteRates
+	if cfg.Routes != nil {
+		routeRates = cfg.Routes
+	}
+	if raw := os.Getenv("RATE_LIMIT_ROUTES"); raw != "" {
+		routeRates = map[string]config.RateRule{}
+		for _, entry := range envList("RATE_LIMIT_ROUTES", nil) {
+			route, rule, ok := parseRouteRate(entry)
+			if !ok {
+				slog.Warn("Regla de RATE_LIMIT_ROUTES inválida, se ignora", "rule", entry)
+				continue
+			}
+			routeRates[route] = rule
+		}
+	}
+
+	opts := limitOptions{
+		perIP:      ratelimit.New(ratelimit.Rule{Rate: perIP.RequestsPerSecond, Burst: perIP.Burst}),
+		routes:     map[string]*ratelimit.Limiter{},
+		maxBody:    64 << 10,
+		bodyRoutes: map[string]int64{"PUT /participants": 10 << 20},
+	}
+	for route, rule := range routeRates {
+		opts.routes[route] = ratelimit.New(ratelimit.Rule{Rate: rule.RequestsPerSecond, Burst: rule.Burst})
+	}
+
+	maxStreams := 10
+	if cfg.MaxStreamsPerIP != 0 {
+		maxStreams = cfg.MaxStreamsPerIP
+	}
+	if n, err := strconv.Atoi(os.Getenv("MAX_STREAMS_PER_IP")); err == nil {
+		maxStreams = n
+	}
+	opts.streams = ratelimit.NewCounter(maxStreams)
+
+	if cfg.MaxBodyBytes != 0 {
+		opts.maxBody = cfg.MaxBodyBytes
+	}
+	if n, err := strconv.ParseInt(os.Getenv("MAX_BODY_BYTES"), 10, 64); err == nil {
+		opts.maxBody = n
+	}
+	if cfg.MaxUploadBytes != 0 {
+		opts.bodyRoutes["PUT /participants"] = cfg.MaxUploadBytes
+	}
+	if n, err := strconv.ParseInt(os.Getenv("MAX_UPLOAD_BYTES"), 10, 64); err == nil {
+		opts.bodyRoutes["PUT /participants"] = n
+	}
+	return opts
+}
+
+// parseRouteRate interpreta "MÉTODO /ruta=tasa:ráfaga".
+func parseRouteRate(entry string) (string, config.RateRule, bool) {
+	route, spec, ok := strings.Cut(entry, "=")
+	if !ok {
+		return "", config.RateRule{}, false
+	}
+	rateRaw, burstRaw, ok := strings.Cut(spec, ":")
+	if !ok {
+		return "", config.RateRule{}, false
+	}
+	rate, err := strconv.ParseFloat(strings.TrimSpace(rateRaw), 64)
+	if err != nil {
+		return "", config.RateRule{}, false
+	}
+	burst, err := strconv.Atoi(strings.TrimSpace(burstRaw))
+	if err != nil {
+		return "", config.RateRule{}, false
+	}
+	return strings.TrimSpace(route), config.RateRule{RequestsPerSecond: rate, Burst: burst}, true
+}
+
+// envList lee una lista separada por comas, o devuelve fallback si la
+// variable no está definida.
+func envList(key string, fallback []string) []string {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// registerAPI declara las rutas del contrato v1 sobre api, que puede ser el
+// grupo versionado o el heredado sin versión.
+func registerAPI(api *gin.RouterGroup, apiHandler *handlers.APIHandler, auth *raffle.AuthService, auditLog *audit.Logger) {
+	api.POST("/auth/login", middleware.AuditLogin(auditLog), apiHandler.Login)
+	api.GET("/openapi.json", apiHandler.OpenAPI)
+	api.GET("/state", apiHandler.GetState)
+	api.GET("/participants", apiHandler.ListParticipants)
+	api.GET("/signing-key", apiHandler.GetSigningKey)
+	api.POST("/results/verify", apiHandler.VerifyResult)
+	api.POST("/spin", middleware.Audit(auditLog, "raffle.spin"), middleware.RequireScope(auth, raffle.ScopeSpin), apiHandler.RegisterSpin)
+	api.POST("/pause", middleware.Audit(auditLog, "raffle.pause"), middleware.RequireScope(auth, raffle.ScopeSpin), apiHandler.Pause)
+	api.POST("/resume", middleware.Audit(auditLog, "raffle.resume"), middleware.RequireScope(auth, raffle.ScopeSpin), apiHandler.Resume)
+	api.PUT("/participants", middleware.Audit(auditLog, "participants.import"), middleware.RequireScope(auth, raffle.ScopeParticipants), apiHandler.UpsertParticipants)
+
+	admin := api.Group("", middleware.RequireSession(auth))
+	{
+		admin.POST("/auth/logout", middleware.Audit(auditLog, "auth.logout"), apiHandler.Logout)
+		admin.POST("/auth/sessions/revoke", middleware.Audit(auditLog, "auth.sessions.revoke"), apiHandler.RevokeSessions)
+		admin.POST("/auth/totp/enroll", middleware.Audit(auditLog, "auth.totp.enroll"), apiHandler.EnrollTOTP)
+		admin.POST("/auth/totp/confirm", middleware.Audit(auditLog, "auth.totp.confirm"), apiHandler.ConfirmTOTP)
+		admin.POST("/auth/totp/disable", middleware.Audit(auditLog, "auth.totp.disable"), apiHandler.DisableTOTP)
+		admin.GET("/keys", apiHandler.ListAPIKeys)
+		admin.POST("/keys", middleware.Audit(auditLog, "apikey.create"), apiHandler.CreateAPIKey)
+		admin.DELETE("/keys/:id", middleware.Audit(auditLog, "apikey.revoke"), apiHandler.RevokeAPIKey)
+		admin.GET("/audit", apiHandler.ListAudit)
+		admin.GET("/audit/verify", apiHandler.VerifyAudit)
+		admin.GET("/events/clients", apiHandler.ListEventClients)
+		admin.POST("/winners/:id/void", middleware.Audit(auditLog, "award.void"), apiHandler.VoidAward)
+		admin.POST("/presenter", middleware.Audit(auditLog, "presenter.command"), apiHandler.Present)
+		admin.GET("/webhooks", apiHandler.ListWebhooks)
+		admin.POST("/webhooks", middleware.Audit(auditLog, "webhook.create"), apiHandler.CreateWebhook)
+		admin.DELETE("/webhooks/:id", middleware.Audit(auditLog, "webhook.delete"), apiHandler.DeleteWebhook)
+		admin.GET("/webhooks/:id/deliveries", apiHandler.ListWebhookDeliveries)
+	}
+}
+
+// adminUsers arma los administradores a partir de ADMIN_PASSWORD (usuario
+// "admin") y de ADMIN_USERS con el formato "usuario:clave,usuario2:clave2".
+func adminUsers() map[string]string {
+	users := map[string]string{"admin": getenv("ADMIN_PASSWORD", "navidad2024")}
+	for _, entry := range strings.Split(os.Getenv("ADMIN_USERS"), ",") {
+		name, pass, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || name == "" || pass == "" {
+			continue
+		}
+		users[name] = pass
+	}
+	return users
+}
+
+func authSecret() []byte {
+	if secret := os.Getenv("AUTH_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+	slog.Warn("AUTH_SECRET no definido, se genera una clave temporal: las sesiones no sobrevivirán a un reinicio")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		fatal("No se pudo generar la clave de sesiones", "error", err)
+	}
+	return secret
+}
+
+func sessionTTL() time.Duration {
+	hours, err := strconv.Atoi(getenv("SESSION_TTL_HOURS", "12"))
+	if err != nil || hours <= 0 {
+		slog.Warn("SESSION_TTL_HOURS inválido, usando 12 horas")
+		hours = 12
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// stateSample es cuántas personas en espera se envían en /api/state y en los
+// eventos "state"; el resto se consulta paginado en /api/participants.
+func stateSample() int {
+	n, err := strconv.Atoi(getenv("STATE_SAMPLE_SIZE", strconv.Itoa(raffle.DefaultStateSample)))
+	if err != nil || n < 0 {
+		slog.Warn("STATE_SAMPLE_SIZE inválido, usando el valor por defecto", "default", raffle.DefaultStateSample)
+		n = raffle.DefaultStateSample
+	}
+	return n
+}
+
+// legacySunset lee API_LEGACY_SUNSET, la fecha (AAAA-MM-DD) en que se
+// retirarán las rutas sin versión. Vacía significa que aún no hay fecha.
+func legacySunset() time.Time {
+	raw := os.Getenv("API_LEGACY_SUNSET")
+	if raw == "" {
+		return time.Time{}
+	}
+	sunset, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		slog.Warn("API_LEGACY_SUNSET inválido, se omite la cabecera Sunset", "value", raw)
+		return time.Time{}
+	}
+	return sunset
+}
+
+// raffleLanguage es el idioma de los mensajes para clientes que no envían
+// Accept-Language o piden uno no soportado. Se toma de raffle.language en
+// CONFIG_PATH; RAFFLE_LANGUAGE tiene prioridad.
+func raffleLanguage() i18n.Lang {
+	fallback := config.Configs().Raffle.Language
+	if fallback == "" {
+		fallback = string(i18n.Default)
+	}
+	raw := getenv("RAFFLE_LANGUAGE", fallback)
+	lang, ok := i18n.Parse(raw)
+	if !ok {
+		slog.Warn("RAFFLE_LANGUAGE inválido, usando el valor por defecto", "value", raw, "default", i18n.Default)
+		return i18n.Default
+	}
+	return lang
+}
+
+// loadSigner usa SIGNING_KEY (semilla Ed25519 en base64) o, si no está
+// definida, el archivo SIGNING_KEY_FILE, que se genera en el primer arranque.
+func loadSigner() (*raffle.Signer, error) {
+	raffleName := config.Configs().Raffle.Name
+	if raffleName == "" {
+		raffleName = "Sorteo Fundasen"
+	}
+	raffleName = getenv("RAFFLE_NAME", raffleName)
+	encoded := os.Getenv("SIGNING_KEY")
+	if encoded == "" {
+		path := getenv("SIGNING_KEY_FILE", "signing.key")
+		content, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			encoded = strings.TrimSpace(string(content))
+		case errors.Is(err, os.ErrNotExist):
+			seed := make([]byte, ed25519.SeedSize)
+			if _, err := rand.Read(seed); err != nil {
+				return nil, err
+			}
+			encoded = base64.StdEncoding.EncodeToString(seed)
+			if err := os.WriteFile(path, []byte(encoded+"\n"), 0o600); err != nil {
+				return nil, err
+			}
+			slog.Info("Se generó una nueva clave de firma", "path", path)
+		default:
+			return nil, err
+		}
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("SIGNING_KEY no es base64 válido: %w", err)
+	}
+	return raffle.NewSigner(raffleName, seed)
+}
+
+// buildEventBus usa Redis cuando EVENT_BUS=redis para que varias réplicas
+// detrás de un balanceador compartan los eventos; si no, reparte en memoria.
+func buildEventBus() (raffle.EventBus, error) {
+	if !strings.EqualFold(os.Getenv("EVENT_BUS"), "redis") {
+		return eventbus.NewInProcess(), nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	bus, err := eventbus.NewRedis(ctx, getenv("REDIS_URL", "redis://localhost:6379/0"), getenv("REDIS_CHANNEL", "sorteos:events"))
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Bus de eventos en Redis activo")
+	return bus, nil
+}
+
+// Valores de INMEMORY_FALLBACK: qué hacer si SQL Server no responde al arrancar.
+const (
+	fallbackAllow    = "allow"
+	fallbackRefuse   = "refuse"
+	fallbackNotReady = "not-ready"
+)
+
+func fallbackPolicy() string {
+	switch policy := strings.ToLower(getenv("INMEMORY_FALLBACK", fallbackAllow)); policy {
+	case fallbackAllow, fallbackRefuse, fallbackNotReady:
+		return policy
+	default:
+		slog.Warn("INMEMORY_FALLBACK inválido", "value", policy, "default", fallbackAllow)
+		return fallbackAllow
+	}
+}
+
+func buildRepository(policy string) (repository.Store, health.Backend, func(), error) {
 	if strings.EqualFold(os.Getenv("USE_INMEMORY"), "true") {
-		log.Printf("USE_INMEMORY activo, usando datos de ejemplo en memoria")
-		return repository.NewInMemoryRepository(), func() {}, nil
+		slog.Info("USE_INMEMORY activo, usando datos de ejemplo en memoria")
+		return repository.NewInMemoryRepository(), health.Backend{Name: "inmemory"}, func() {}, nil
 	}
 
 	repo, cleanup, err := buildSQLServerRepository()
 	if err == nil {
-		return repo, cleanup, nil
+		return repo, health.Backend{Name: "sqlserver"}, cleanup, nil
 	}
 
-	log.Printf("No se pudo conectar a SQL Server (%v). Activando repositorio en memoria para que el servidor continúe ejecutándose", err)
-	return repository.NewInMemoryRepository(), func() {}, nil
+	if policy == fallbackRefuse {
+		return nil, health.Backend{}, func() {}, err
+	}
+	slog.Warn("No se pudo conectar a SQL Server. Activando repositorio en memoria para que el servidor continúe ejecutándose", "error", err)
+	return repository.NewInMemoryRepository(), health.Backend{Name: "inmemory", Fallback: true}, func() {}, nil
 }
 
-func buildSQLServerRepository() (repository.Repository, func(), error) {
+func buildSQLServerRepository() (repository.Store, func(), error) {
 	db, err := connectSQLServer()
 	if err != nil {
 		return nil, func() {}, err
 	}
 
-	log.Printf("Conectado a SQL Server correctamente")
+	slog.Info("Conectado a SQL Server correctamente")
 	cleanup := func() {
 		if err := db.Close(); err != nil {
-			log.Printf("Error al cerrar la conexión a SQL Server: %v", err)
+			slog.Error("Error al cerrar la conexión a SQL Server", "error", err)
 		}
 	}
 	return repository.NewSQLServerRepository(db), cleanup, nil
@@ -40,12 +40,161 @@ BEGIN
         persona_id INT NOT NULL,
         premio_id INT NOT NULL,
         entregado_en DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME(),
+        semilla NVARCHAR(64) NULL,
+        firma NVARCHAR(128) NULL,
         CONSTRAINT FK_ganadores_persona FOREIGN KEY (persona_id) REFERENCES personas(id),
         CONSTRAINT FK_ganadores_premio FOREIGN KEY (premio_id) REFERENCES premios(id)
     );
 END
 GO
 
+IF COL_LENGTH('ganadores', 'semilla') IS NULL
+BEGIN
+    ALTER TABLE ganadores ADD semilla NVARCHAR(64) NULL, firma NVARCHAR(128) NULL;
+END
+GO
+
+-- Un premio anulado sale de ganadores y queda aquí, así la persona y el
+-- premio vuelven al sorteo sin perder el registro original ni su firma.
+IF OBJECT_ID('premios_anulados', 'U') IS NULL
+BEGIN
+    CREATE TABLE premios_anulados (
+        id INT IDENTITY(1,1) PRIMARY KEY,
+        ganador_id INT NOT NULL,
+        persona_id INT NOT NULL,
+        premio_id INT NOT NULL,
+        entregado_en DATETIME2 NOT NULL,
+        semilla NVARCHAR(64) NULL,
+        firma NVARCHAR(128) NULL,
+        motivo NVARCHAR(500) NOT NULL,
+        anulado_por NVARCHAR(200) NOT NULL,
+        anulado_en DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME(),
+        CONSTRAINT FK_premios_anulados_persona FOREIGN KEY (persona_id) REFERENCES personas(id),
+        CONSTRAINT FK_premios_anulados_premio FOREIGN KEY (premio_id) REFERENCES premios(id)
+    );
+END
+GO
+
+IF OBJECT_ID('sesiones_revocadas', 'U') IS NULL
+BEGIN
+    CREATE TABLE sesiones_revocadas (
+        id NVARCHAR(64) PRIMARY KEY,
+        expira_en DATETIME2 NOT NULL,
+        revocado_en DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME()
+    );
+END
+GO
+
+IF OBJECT_ID('revocaciones_globales', 'U') IS NULL
+BEGIN
+    CREATE TABLE revocaciones_globales (
+        id INT IDENTITY(1,1) PRIMARY KEY,
+        revocado_antes_de DATETIME2 NOT NULL,
+        creado_en DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME()
+    );
+END
+GO
+
+IF OBJECT_ID('admin_totp', 'U') IS NULL
+BEGIN
+    CREATE TABLE admin_totp (
+        usuario NVARCHAR(100) PRIMARY KEY,
+        secreto NVARCHAR(64) NOT NULL,
+        confirmado BIT NOT NULL DEFAULT 0,
+        ultimo_paso BIGINT NOT NULL DEFAULT 0,
+        creado_en DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME()
+    );
+END
+GO
+
+IF OBJECT_ID('admin_totp_recuperacion', 'U') IS NULL
+BEGIN
+    CREATE TABLE admin_totp_recuperacion (
+        id INT IDENTITY(1,1) PRIMARY KEY,
+        usuario NVARCHAR(100) NOT NULL,
+        codigo_hash CHAR(64) NOT NULL,
+        usado_en DATETIME2 NULL,
+        CONSTRAINT FK_admin_totp_recuperacion_usuario FOREIGN KEY (usuario) REFERENCES admin_totp(usuario)
+    );
+END
+GO
+
+IF OBJECT_ID('api_keys', 'U') IS NULL
+BEGIN
+    CREATE TABLE api_keys (
+        id INT IDENTITY(1,1) PRIMARY KEY,
+        nombre NVARCHAR(200) NOT NULL,
+        prefijo NVARCHAR(32) NOT NULL,
+        hash CHAR(64) NOT NULL UNIQUE,
+        alcances NVARCHAR(500) NOT NULL,
+        creado_por NVARCHAR(100) NOT NULL,
+        creado_en DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME(),
+        usado_en DATETIME2 NULL,
+        revocado_en DATETIME2 NULL
+    );
+END
+GO
+
+IF OBJECT_ID('auditoria', 'U') IS NULL
+BEGIN
+    CREATE TABLE auditoria (
+        id BIGINT IDENTITY(1,1) PRIMARY KEY,
+        actor NVARCHAR(200) NOT NULL,
+        accion NVARCHAR(100) NOT NULL,
+        payload NVARCHAR(MAX) NULL,
+        ip NVARCHAR(64) NOT NULL,
+        user_agent NVARCHAR(500) NOT NULL,
+        resultado NVARCHAR(20) NOT NULL,
+        estado INT NOT NULL,
+        creado_en DATETIME2 NOT NULL,
+        hash_anterior NVARCHAR(64) NOT NULL,
+        hash CHAR(64) NOT NULL
+    );
+    CREATE INDEX IX_auditoria_actor_accion ON auditoria (actor, accion, creado_en);
+END
+GO
+
+-- La bitácora es de solo inserción: cualquier UPDATE o DELETE se rechaza.
+CREATE OR ALTER TRIGGER TR_auditoria_solo_insercion ON auditoria
+INSTEAD OF UPDATE, DELETE
+AS
+BEGIN
+    THROW 51000, 'La tabla auditoria es de solo inserción', 1;
+END
+GO
+
+IF OBJECT_ID('webhooks', 'U') IS NULL
+BEGIN
+    CREATE TABLE webhooks (
+        id INT IDENTITY(1,1) PRIMARY KEY,
+        url NVARCHAR(2000) NOT NULL,
+        eventos NVARCHAR(500) NOT NULL,
+        secreto NVARCHAR(200) NOT NULL,
+        creado_por NVARCHAR(100) NOT NULL,
+        creado_en DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME(),
+        eliminado_en DATETIME2 NULL
+    );
+END
+GO
+
+IF OBJECT_ID('webhook_entregas', 'U') IS NULL
+BEGIN
+    CREATE TABLE webhook_entregas (
+        id BIGINT IDENTITY(1,1) PRIMARY KEY,
+        webhook_id INT NOT NULL REFERENCES webhooks(id),
+        evento_id BIGINT NOT NULL,
+        tipo_evento NVARCHAR(50) NOT NULL,
+        intento INT NOT NULL,
+        estado_http INT NOT NULL,
+        error NVARCHAR(1000) NOT NULL,
+        exitoso BIT NOT NULL,
+        duracion_ms BIGINT NOT NULL,
+        creado_en DATETIME2 NOT NULL
+    );
+    CREATE INDEX IX_webhook_entregas_webhook ON webhook_entregas (webhook_id, id DESC);
+END
+GO
+
 -- Semillas opcionales para pruebas locales
 INSERT INTO personas (nombre, email)
 VALUES
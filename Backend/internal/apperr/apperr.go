@@ -0,0 +1,50 @@
+package apperr
+
+import "errors"
+
+// CodeInternal es el código de cualquier error que no sea de dominio.
+const CodeInternal = "INTERNAL"
+
+// Error es un error de dominio con un código estable, para que los clientes
+// reaccionen al código y no al texto del mensaje.
+type Error struct {
+	Code    string
+	Message string
+	// Field indica el parámetro afectado, para los mensajes que lo mencionan.
+	Field string
+}
+
+func New(code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is compara por código, así una copia con otro mensaje sigue coincidiendo
+// con el error original en errors.Is.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithField devuelve el mismo error referido a un parámetro concreto.
+func (e *Error) WithField(field, message string) *Error {
+	return &Error{Code: e.Code, Message: message, Field: field}
+}
+
+// Code devuelve el código del primer error de dominio de la cadena.
+func Code(err error) string {
+	if appErr, ok := As(err); ok {
+		return appErr.Code
+	}
+	return CodeInternal
+}
+
+// As busca el primer error de dominio de la cadena.
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	ok := errors.As(err, &appErr)
+	return appErr, ok
+}
@@ -0,0 +1,148 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"apiSorteos/internal/models"
+	"apiSorteos/internal/repository"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+
+	defaultLimit = 100
+	maxLimit     = 1000
+	verifyPage   = 500
+)
+
+// Logger escribe la bitácora encadenando cada registro con el hash del anterior.
+type Logger struct {
+	store repository.AuditStore
+}
+
+func NewLogger(store repository.AuditStore) *Logger {
+	return &Logger{store: store}
+}
+
+func (l *Logger) Record(ctx context.Context, entry models.AuditEntry) (models.AuditEntry, error) {
+	// La base guarda microsegundos; se trunca antes de calcular el hash para que la verificación coincida.
+	entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	return l.store.AppendAudit(ctx, func(prevHash string) models.AuditEntry {
+		entry.PrevHash = prevHash
+		entry.Hash = Hash(entry)
+		return entry
+	})
+}
+
+func (l *Logger) List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultLimit
+	}
+	filter.Limit = min(filter.Limit, maxLimit)
+	return l.store.ListAudit(ctx, filter)
+}
+
+// VerifyResult indica si la cadena está íntegra y, si no, el primer registro alterado.
+type VerifyResult struct {
+	Valid    bool   `json:"valid"`
+	Checked  int    `json:"checked"`
+	BrokenAt int64  `json:"brokenAt,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+func (l *Logger) Verify(ctx context.Context) (VerifyResult, error) {
+	result := VerifyResult{Valid: true}
+	var afterID int64
+	prevHash := ""
+	for {
+		entries, err := l.store.ListAuditChain(ctx, afterID, verifyPage)
+		if err != nil {
+			return VerifyResult{}, err
+		}
+		for _, e := range entries {
+			result.Checked++
+			switch {
+			case e.PrevHash != prevHash:
+				return VerifyResult{Checked: result.Checked, BrokenAt: e.ID, Reason: "hash anterior no coincide"}, nil
+			case Hash(e) != e.Hash:
+				return VerifyResult{Checked: result.Checked, BrokenAt: e.ID, Reason: "contenido modificado"}, nil
+			}
+			prevHash = e.Hash
+			afterID = e.ID
+		}
+		if len(entries) < verifyPage {
+			return result, nil
+		}
+	}
+}
+
+// Hash calcula el sha256 de los campos del registro junto con PrevHash. El id
+// no participa porque lo asigna la base al insertar.
+func Hash(e models.AuditEntry) string {
+	fields := []string{
+		e.PrevHash,
+		e.Actor,
+		e.Action,
+		string(e.Payload),
+		e.IP,
+		e.UserAgent,
+		e.Outcome,
+		strconv.Itoa(e.Status),
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+	// json.Marshal escapa los separadores, así dos registros distintos no pueden producir el mismo texto.
+	canonical, _ := json.Marshal(fields)
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
+
+var sensitiveFields = []string{"password", "code", "secret", "key", "token"}
+
+// Redact reemplaza contraseñas, códigos y claves del cuerpo antes de guardarlo.
+func Redact(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		quoted, _ := json.Marshal(string(body))
+		return quoted
+	}
+	redacted, _ := json.Marshal(redactValue(value))
+	return redacted
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, inner := range v {
+			if isSensitive(k) {
+				v[k] = "***"
+				continue
+			}
+			v[k] = redactValue(inner)
+		}
+	case []any:
+		for i := range v {
+			v[i] = redactValue(v[i])
+		}
+	}
+	return value
+}
+
+func isSensitive(field string) bool {
+	field = strings.ToLower(field)
+	for _, s := range sensitiveFields {
+		if field == s {
+			return true
+		}
+	}
+	return false
+}
@@ -3,17 +3,33 @@ package config
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
-	"path/filepath"
-	"time"
 )
 
 type Config struct {
 	Server struct {
 		Port        int    `json:"port"`
 		Environment string `json:"environment"`
+		TLS         struct {
+			CertFile string `json:"certFile"`
+			KeyFile  string `json:"keyFile"`
+			// SelfSigned genera CertFile/KeyFile en el primer arranque si no existen.
+			SelfSigned   bool     `json:"selfSigned"`
+			Hosts        []string `json:"hosts"`
+			RedirectPort int      `json:"redirectPort"`
+		} `json:"tls"`
+		// TrustedProxies lista las IP o redes CIDR de los proxies cuyo
+		// X-Forwarded-For se acepta; vacío ignora la cabecera.
+		TrustedProxies []string `json:"trustedProxies"`
 	} `json:"server"`
+	// Raffle describe el sorteo que atiende este servidor.
+	Raffle struct {
+		// Name es el nombre que se firma en cada resultado.
+		Name string `json:"name"`
+		// Language ("es" o "en") se usa con clientes que no envían
+		// Accept-Language o piden un idioma no soportado.
+		Language string `json:"language"`
+	} `json:"raffle"`
 	Database struct {
 		Host       string `json:"host"`
 		Port       int    `json:"port"`
@@ -28,43 +44,56 @@ type Config struct {
 		ExpirationHours int    `json:"expirationHours"`
 	} `json:"jwt"`
 	Logs struct {
-		Path string `json:"path"`
+		// Path es la base del nombre: se escribe en "<path sin extensión>_<fecha><ext>".
+		Path       string `json:"path"`
+		Level      string `json:"level"`
+		MaxSizeMB  int    `json:"maxSizeMB"`
+		MaxBackups int    `json:"maxBackups"`
 	} `json:"logs"`
+	Cors struct {
+		AllowedOrigins   []string `json:"allowedOrigins"`
+		AllowedMethods   []string `json:"allowedMethods"`
+		AllowedHeaders   []string `json:"allowedHeaders"`
+		AllowCredentials bool     `json:"allowCredentials"`
+		MaxAgeSeconds    int      `json:"maxAgeSeconds"`
+	} `json:"cors"`
+	Limits struct {
+		RequestsPerSecond float64 `json:"requestsPerSecond"`
+		Burst             int     `json:"burst"`
+		// Routes usa claves como "GET /state" o "POST /auth/login", sin el
+		// prefijo /api ni la versión.
+		Routes          map[string]RateRule `json:"routes"`
+		MaxStreamsPerIP int                 `json:"maxStreamsPerIP"`
+		MaxBodyBytes    int64               `json:"maxBodyBytes"`
+		MaxUploadBytes  int64               `json:"maxUploadBytes"`
+	} `json:"limits"`
 }
 
-var configs *Config
+type RateRule struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	Burst             int     `json:"burst"`
+}
+
+var configs = &Config{}
 
-func LoadConfig(path string) {
+// LoadConfig lee el archivo JSON de configuración. La rotación de logs la
+// hace ahora el paquete logging mientras el servidor corre.
+func LoadConfig(path string) error {
 	file, err := os.Open(path)
 	if err != nil {
-		log.Fatal("No se ha encontrado el archivo de configuraciones JSON")
+		return fmt.Errorf("no se ha encontrado el archivo de configuraciones JSON: %w", err)
 	}
 	defer file.Close()
-	configs = &Config{}
-	if err := json.NewDecoder(file).Decode(configs); err != nil {
-		log.Fatalf("Archivo JSON con errores en estructura: %v", err)
-	}
 
-	logDir := filepath.Dir(configs.Logs.Path)
-	if _, err := os.Stat(logDir); os.IsNotExist(err) {
-		if mkErr := os.MkdirAll(logDir, os.ModePerm); mkErr != nil {
-			log.Fatalf("No se puede crear carpetas en la ubicacion %v", mkErr)
-		}
-	}
-
-	date := time.Now().Format("2006-01-02") // YYYY-MM-DD
-	ext := filepath.Ext(configs.Logs.Path)
-	base := configs.Logs.Path[:len(configs.Logs.Path)-len(ext)]
-	logPath := fmt.Sprintf("%s_%s%s", base, date, ext)
-
-	logFile, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		fmt.Println("Error accediendo al log :'(")
+	loaded := &Config{}
+	if err := json.NewDecoder(file).Decode(loaded); err != nil {
+		return fmt.Errorf("archivo JSON con errores en estructura: %w", err)
 	}
-	log.SetOutput(logFile)
-	//defer logFile.Close()
+	configs = loaded
+	return nil
 }
 
+// Configs devuelve la configuración cargada, o una vacía si no se usó CONFIG_PATH.
 func Configs() *Config {
 	return configs
 }
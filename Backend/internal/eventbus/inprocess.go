@@ -0,0 +1,44 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+
+	"apiSorteos/internal/raffle"
+)
+
+// InProcess entrega los eventos dentro del mismo proceso. Es la opción por
+// defecto cuando hay una sola réplica del servidor.
+type InProcess struct {
+	mu          sync.Mutex
+	lastID      uint64
+	subscribers []func(raffle.Event)
+}
+
+func NewInProcess() *InProcess {
+	return &InProcess{}
+}
+
+func (b *InProcess) Publish(_ context.Context, evt raffle.Event) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	evt.ID = b.lastID
+	for _, deliver := range b.subscribers {
+		deliver(evt)
+	}
+	return evt.ID, nil
+}
+
+func (b *InProcess) Subscribe(deliver func(raffle.Event)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers = append(b.subscribers, deliver)
+	return nil
+}
+
+func (b *InProcess) Close() error {
+	return nil
+}
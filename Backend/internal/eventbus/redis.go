@@ -0,0 +1,162 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"apiSorteos/internal/raffle"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// historyLength es cuántos mensajes guarda Redis para recuperar los que una
+// réplica pierda, por ejemplo mientras se reconecta al canal.
+const historyLength = 256
+
+// publishScript asigna el id, guarda el mensaje en el historial y lo publica
+// en un solo paso: sin él, dos réplicas podían publicar sus ids fuera de orden.
+var publishScript = redis.NewScript(`
+local id = redis.call('INCR', KEYS[1])
+local msg = '{"id":' .. id .. ',"event":' .. ARGV[2] .. '}'
+redis.call('RPUSH', KEYS[2], msg)
+redis.call('LTRIM', KEYS[2], -tonumber(ARGV[3]), -1)
+redis.call('PUBLISH', ARGV[1], msg)
+return id
+`)
+
+// message es lo que viaja por el canal y se guarda en el historial.
+type message struct {
+	ID    uint64       `json:"id"`
+	Event raffle.Event `json:"event"`
+}
+
+// Redis reparte los eventos entre réplicas con pub/sub. Los ids salen de un
+// contador compartido (INCR) para que Last-Event-ID funcione aunque el
+// cliente se reconecte a otra réplica.
+type Redis struct {
+	client     *redis.Client
+	channel    string
+	seqKey     string
+	historyKey string
+
+	mu     sync.Mutex
+	pubsub *redis.PubSub
+	done   chan struct{}
+}
+
+func NewRedis(ctx context.Context, url, channel string) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("REDIS_URL inválida: %w", err)
+	}
+	client := redis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("no se pudo conectar a Redis: %w", err)
+	}
+	return &Redis{
+		client:     client,
+		channel:    channel,
+		seqKey:     channel + ":seq",
+		historyKey: channel + ":history",
+		done:       make(chan struct{}),
+	}, nil
+}
+
+func (b *Redis) Publish(ctx context.Context, evt raffle.Event) (uint64, error) {
+	return b.publish(ctx, b.channel, evt)
+}
+
+func (b *Redis) publish(ctx context.Context, channel string, evt raffle.Event) (uint64, error) {
+	evt.ID = 0
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return 0, err
+	}
+	id, err := publishScript.Run(ctx, b.client, []string{b.seqKey, b.historyKey}, channel, payload, historyLength).Uint64()
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (b *Redis) Subscribe(deliver func(raffle.Event)) error {
+	ctx := context.Background()
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	// Receive confirma la suscripción antes de devolver el control.
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return fmt.Errorf("no se pudo suscribir al canal %s: %w", b.channel, err)
+	}
+
+	b.mu.Lock()
+	b.pubsub = pubsub
+	b.mu.Unlock()
+
+	go func() {
+		var lastID uint64
+		for {
+			select {
+			case raw, ok := <-pubsub.Channel():
+				if !ok {
+					return
+				}
+				var msg message
+				if err := json.Unmarshal([]byte(raw.Payload), &msg); err != nil {
+					slog.Warn("Evento inválido recibido de Redis", "error", err)
+					continue
+				}
+				if msg.ID <= lastID {
+					continue
+				}
+				if lastID != 0 && msg.ID > lastID+1 {
+					for _, missed := range b.missed(ctx, lastID, msg.ID) {
+						deliver(missed)
+					}
+				}
+				lastID = msg.ID
+				msg.Event.ID = msg.ID
+				deliver(msg.Event)
+			case <-b.done:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// missed busca en el historial los eventos entre after y before que no
+// llegaron por el canal. Si ya no están, el servicio detecta el hueco y
+// reenvía el estado completo a sus clientes.
+func (b *Redis) missed(ctx context.Context, after, before uint64) []raffle.Event {
+	entries, err := b.client.LRange(ctx, b.historyKey, 0, -1).Result()
+	if err != nil {
+		slog.Warn("No se pudo leer el historial de eventos de Redis", "error", err)
+		return nil
+	}
+	var events []raffle.Event
+	for _, entry := range entries {
+		var msg message
+		if err := json.Unmarshal([]byte(entry), &msg); err != nil || msg.ID <= after || msg.ID >= before {
+			continue
+		}
+		msg.Event.ID = msg.ID
+		events = append(events, msg.Event)
+	}
+	slog.Warn("Se recuperaron eventos perdidos del historial de Redis", "after", after, "before", before, "recovered", len(events))
+	return events
+}
+
+func (b *Redis) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	close(b.done)
+	if b.pubsub != nil {
+		b.pubsub.Close()
+	}
+	return b.client.Close()
+}
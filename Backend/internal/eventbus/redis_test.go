@@ -0,0 +1,128 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"apiSorteos/internal/raffle"
+)
+
+// newTestRedis necesita un Redis real en REDIS_ADDR (host:puerto); sin esa
+// variable la prueba se omite. Cada prueba usa un canal propio y lo limpia.
+func newTestRedis(t *testing.T) *Redis {
+	t.Helper()
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_ADDR no está definido")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	channel := fmt.Sprintf("sorteos:test:%s:%d", t.Name(), time.Now().UnixNano())
+	bus, err := NewRedis(ctx, "redis://"+addr, channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		bus.client.Del(context.Background(), bus.seqKey, bus.historyKey)
+		bus.Close()
+	})
+	return bus
+}
+
+func subscribe(t *testing.T, bus *Redis) <-chan raffle.Event {
+	t.Helper()
+	events := make(chan raffle.Event, 16)
+	if err := bus.Subscribe(func(evt raffle.Event) { events <- evt }); err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func receive(t *testing.T, events <-chan raffle.Event) raffle.Event {
+	t.Helper()
+	select {
+	case evt := <-events:
+		return evt
+	case <-time.After(5 * time.Second):
+		t.Fatal("no llegó ningún evento")
+		return raffle.Event{}
+	}
+}
+
+func TestRedisPublishAssignsSequentialIDs(t *testing.T) {
+	bus := newTestRedis(t)
+	events := subscribe(t, bus)
+	ctx := context.Background()
+
+	for i := 1; i <= 3; i++ {
+		id, err := bus.Publish(ctx, raffle.Event{Type: "paused", Version: uint64(i), Data: map[string]int{"n": i}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != uint64(i) {
+			t.Errorf("Publish devolvió el id %d, se esperaba %d", id, i)
+		}
+	}
+	for i := 1; i <= 3; i++ {
+		evt := receive(t, events)
+		if evt.ID != uint64(i) || evt.Type != "paused" || evt.Version != uint64(i) {
+			t.Errorf("evento %d = %+v", i, evt)
+		}
+	}
+
+	history, err := bus.client.LLen(ctx, bus.historyKey).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history != 3 {
+		t.Errorf("el historial tiene %d mensajes, se esperaban 3", history)
+	}
+}
+
+func TestRedisRecoversMissedEvents(t *testing.T) {
+	bus := newTestRedis(t)
+	events := subscribe(t, bus)
+	ctx := context.Background()
+
+	if _, err := bus.Publish(ctx, raffle.Event{Type: "paused", Data: struct{}{}}); err != nil {
+		t.Fatal(err)
+	}
+	receive(t, events)
+
+	// Publicar en otro canal simula un mensaje que la réplica no recibió:
+	// consume un id y queda en el historial compartido.
+	if _, err := bus.publish(ctx, bus.channel+":perdido", raffle.Event{Type: "resumed", Data: struct{}{}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bus.Publish(ctx, raffle.Event{Type: "paused", Data: struct{}{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []raffle.Event{{ID: 2, Type: "resumed"}, {ID: 3, Type: "paused"}} {
+		got := receive(t, events)
+		if got.ID != want.ID || got.Type != want.Type {
+			t.Errorf("evento = %d %s, se esperaba %d %s", got.ID, got.Type, want.ID, want.Type)
+		}
+	}
+}
+
+func TestRedisHistoryIsBounded(t *testing.T) {
+	bus := newTestRedis(t)
+	ctx := context.Background()
+
+	for i := 0; i < historyLength+10; i++ {
+		if _, err := bus.Publish(ctx, raffle.Event{Type: "paused", Data: struct{}{}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n, err := bus.client.LLen(ctx, bus.historyKey).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != historyLength {
+		t.Errorf("el historial tiene %d mensajes, se esperaban %d", n, historyLength)
+	}
+}
@@ -2,95 +2,318 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"apiSorteos/internal/audit"
+	"apiSorteos/internal/health"
+	"apiSorteos/internal/i18n"
+	"apiSorteos/internal/metrics"
+	"apiSorteos/internal/middleware"
+	"apiSorteos/internal/models"
 	"apiSorteos/internal/raffle"
-	"apiSorteos/internal/repository"
+	"apiSorteos/internal/webhooks"
 
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 )
 
 type APIHandler struct {
-	service *raffle.Service
-	auth    *raffle.AuthService
+	service  *raffle.Service
+	auth     *raffle.AuthService
+	audit    *audit.Logger
+	webhooks *webhooks.Dispatcher
+	health   *health.Checker
+	metrics  *metrics.Metrics
+	upgrader *websocket.Upgrader
 }
 
-func NewAPIHandler(service *raffle.Service, auth *raffle.AuthService) *APIHandler {
-	return &APIHandler{service: service, auth: auth}
+func NewAPIHandler(service *raffle.Service, auth *raffle.AuthService, auditLog *audit.Logger, hooks *webhooks.Dispatcher, checker *health.Checker, m *metrics.Metrics) *APIHandler {
+	return &APIHandler{
+		service:  service,
+		auth:     auth,
+		audit:    auditLog,
+		webhooks: hooks,
+		health:   checker,
+		metrics:  m,
+		upgrader: newUpgrader(middleware.CORSOptions{}),
+	}
 }
 
 func (h *APIHandler) Login(c *gin.Context) {
 	var payload struct {
+		Username string `json:"username"`
 		Password string `json:"password"`
+		Code     string `json:"code"`
 	}
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "cuerpo inválido"})
+		_ = c.Error(middleware.InvalidBody(err))
 		return
 	}
-	token, err := h.auth.Login(payload.Password)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	if payload.Username == "" {
+		payload.Username = "admin"
+	}
+	middleware.SetAuditActor(c, payload.Username)
+	token, err := h.auth.Login(c.Request.Context(), payload.Username, payload.Password, payload.Code)
+	switch {
+	case errors.Is(err, raffle.ErrTOTPRequired):
+		h.metrics.ObserveLogin(metrics.LoginTOTPRequired)
+		_ = c.Error(err).SetMeta(gin.H{"totpRequired": true})
+		return
+	case errors.Is(err, raffle.ErrInvalidPassword), errors.Is(err, raffle.ErrInvalidTOTP):
+		h.metrics.ObserveLogin(metrics.LoginFailure)
+		_ = c.Error(err)
+		return
+	case err != nil:
+		h.metrics.ObserveLogin(metrics.LoginError)
+		_ = c.Error(err)
 		return
 	}
+	h.metrics.ObserveLogin(metrics.LoginSuccess)
 	c.JSON(http.StatusOK, gin.H{"token": token})
 }
 
+func (h *APIHandler) Logout(c *gin.Context) {
+	if err := h.auth.Logout(c.Request.Context(), bearerToken(c)); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *APIHandler) RevokeSessions(c *gin.Context) {
+	if err := h.auth.RevokeAll(c.Request.Context()); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *APIHandler) EnrollTOTP(c *gin.Context) {
+	setup, err := h.auth.BeginTOTPEnrollment(c.Request.Context(), middleware.CurrentPrincipal(c).Subject)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, setup)
+}
+
+func (h *APIHandler) ConfirmTOTP(c *gin.Context) {
+	var payload struct {
+		Code string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		_ = c.Error(middleware.InvalidBody(err))
+		return
+	}
+	codes, err := h.auth.ConfirmTOTP(c.Request.Context(), middleware.CurrentPrincipal(c).Subject, payload.Code)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
+
+func (h *APIHandler) DisableTOTP(c *gin.Context) {
+	var payload struct {
+		Code string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		_ = c.Error(middleware.InvalidBody(err))
+		return
+	}
+	if err := h.auth.DisableTOTP(c.Request.Context(), middleware.CurrentPrincipal(c).Subject, payload.Code); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
 func (h *APIHandler) GetState(c *gin.Context) {
 	state, err := h.service.State(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, state)
 }
 
+func (h *APIHandler) ListParticipants(c *gin.Context) {
+	paging := map[string]int{"page": 0, "pageSize": 0}
+	for param := range paging {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			_ = c.Error(middleware.InvalidParameter(param))
+			return
+		}
+		paging[param] = n
+	}
+
+	page, err := h.service.SearchParticipants(c.Request.Context(), c.Query("status"), c.Query("q"), paging["page"], paging["pageSize"])
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
 func (h *APIHandler) RegisterSpin(c *gin.Context) {
 	var payload struct {
 		ParticipantID int `json:"participantId"`
 		PrizeID       int `json:"prizeId"`
 	}
-	_ = c.ShouldBindJSON(&payload)
+	// El cuerpo es opcional: sin él el servicio responde NOTHING_TO_REGISTER.
+	if err := c.ShouldBindJSON(&payload); err != nil && !errors.Is(err, io.EOF) {
+		_ = c.Error(middleware.InvalidBody(err))
+		return
+	}
 
 	record, err := h.service.RegisterSpin(c.Request.Context(), payload.ParticipantID, payload.PrizeID)
+	h.observeSpin(err)
 	if err != nil {
-		status := http.StatusInternalServerError
-		if err == repository.ErrNoParticipants || err == repository.ErrNoPrizes || err == repository.ErrPrizeUnavailable || err == repository.ErrParticipantUsed || err == repository.ErrNothingToRegister {
-			status = http.StatusConflict
-		}
-		c.JSON(status, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, record)
 }
 
-func (h *APIHandler) AdminOnly(next gin.HandlerFunc) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		token := strings.TrimPrefix(authHeader, "Bearer ")
-		if !h.auth.Validate(token) {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "No autorizado"})
-			return
-		}
-		next(c)
+func (h *APIHandler) VoidAward(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		_ = c.Error(middleware.InvalidParameter("id"))
+		return
+	}
+	var payload struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		_ = c.Error(middleware.InvalidBody(err))
+		return
+	}
+	voided, err := h.service.VoidAward(c.Request.Context(), id, payload.Reason, middleware.CurrentPrincipal(c).Subject)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, voided)
+}
+
+func (h *APIHandler) observeSpin(err error) {
+	switch {
+	case err == nil:
+		h.metrics.ObserveSpin(metrics.SpinSuccess)
+	case middleware.StatusOf(err) == http.StatusConflict:
+		h.metrics.ObserveSpin(metrics.SpinConflict)
+	default:
+		h.metrics.ObserveSpin(metrics.SpinError)
 	}
 }
 
+func (h *APIHandler) Pause(c *gin.Context) {
+	h.service.Pause(c.Request.Context())
+	c.Status(http.StatusNoContent)
+}
+
+func (h *APIHandler) Resume(c *gin.Context) {
+	h.service.Resume(c.Request.Context())
+	c.Status(http.StatusNoContent)
+}
+
+func (h *APIHandler) UpsertParticipants(c *gin.Context) {
+	var people []models.Person
+	if err := c.ShouldBindJSON(&people); err != nil {
+		_ = c.Error(middleware.InvalidBody(err))
+		return
+	}
+	created, updated, err := h.service.UpsertParticipants(c.Request.Context(), people)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"created": created, "updated": updated})
+}
+
+func bearerToken(c *gin.Context) string {
+	return strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+}
+
 func (h *APIHandler) StreamEvents(c *gin.Context) {
-	client := h.service.RegisterClient()
-	go func() {
-		<-c.Request.Context().Done()
-		h.service.UnregisterClient(client)
-	}()
+	ctx := c.Request.Context()
+	client := h.service.RegisterClient(lastEventID(c))
+	defer h.service.UnregisterClient(client)
+
+	heartbeat := time.NewTicker(raffle.HeartbeatInterval)
+	defer heartbeat.Stop()
+
 	c.Stream(func(w io.Writer) bool {
-		if evt, ok := <-client.Chan(); ok {
-			c.SSEvent(evt.Type, evt.Data)
-			return true
+		select {
+		case evt, ok := <-client.Chan():
+			if !ok {
+				return false
+			}
+			writeEvent(c, evt)
+		case <-client.Resync():
+			writeEvent(c, h.service.ResyncEvent(ctx, client))
+		case <-heartbeat.C:
+			_, _ = io.WriteString(w, ": heartbeat\n\n")
+		case <-ctx.Done():
+			return false
 		}
-		return false
+		return true
 	})
-	h.service.UnregisterClient(client)
+}
+
+func (h *APIHandler) ListEventClients(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.ClientStats())
+}
+
+// lastEventID lee la cabecera que envía EventSource al reconectarse; el
+// parámetro lastEventId sirve para clientes que no pueden fijar cabeceras.
+func lastEventID(c *gin.Context) uint64 {
+	raw := c.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = c.Query("lastEventId")
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+func writeEvent(c *gin.Context, evt raffle.Event) {
+	evt = localizeEvent(evt, middleware.CurrentLanguage(c))
+	msg := sse.Event{Event: evt.Type, Data: evt.Data}
+	if evt.ID != 0 {
+		msg.Id = strconv.FormatUint(evt.ID, 10)
+	}
+	c.Render(-1, msg)
+}
+
+// localizeEvent traduce los eventos "error" al idioma de la conexión.
+func localizeEvent(evt raffle.Event, lang i18n.Lang) raffle.Event {
+	if evt.Type != "error" {
+		return evt
+	}
+	data, ok := evt.Data.(raffle.ErrorData)
+	if !ok {
+		// Los eventos que llegan por Redis se decodifican como mapas.
+		raw, _ := evt.Data.(map[string]interface{})
+		data.Code, _ = raw["code"].(string)
+		data.Message, _ = raw["message"].(string)
+	}
+	data.Message = i18n.Text(lang, data.Code, "", data.Message)
+	evt.Data = data
+	return evt
 }
 
 func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
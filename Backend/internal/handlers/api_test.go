@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLastEventID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name   string
+		header string
+		query  string
+		want   uint64
+	}{
+		{"cabecera", "42", "", 42},
+		{"parámetro", "", "17", 17},
+		{"la cabecera tiene prioridad", "42", "17", 42},
+		{"basura", "abc", "", 0},
+		{"negativo", "-5", "", 0},
+		{"desborda uint64", "18446744073709551616", "", 0},
+		{"sin id", "", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/events?lastEventId="+tt.query, nil)
+			if tt.header != "" {
+				c.Request.Header.Set("Last-Event-ID", tt.header)
+			}
+			if got := lastEventID(c); got != tt.want {
+				t.Errorf("lastEventID = %d, se esperaba %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"apiSorteos/internal/middleware"
+	"apiSorteos/internal/raffle"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *APIHandler) CreateAPIKey(c *gin.Context) {
+	var payload struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		_ = c.Error(middleware.InvalidBody(err))
+		return
+	}
+	key, plain, err := h.auth.CreateAPIKey(c.Request.Context(), payload.Name, payload.Scopes, middleware.CurrentPrincipal(c).Subject)
+	if err != nil {
+		var meta interface{}
+		if errors.Is(err, raffle.ErrInvalidScope) {
+			meta = gin.H{"knownScopes": raffle.KnownScopes}
+		}
+		_ = c.Error(err).SetMeta(meta)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"apiKey": key, "key": plain})
+}
+
+func (h *APIHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.auth.ListAPIKeys(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+func (h *APIHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		_ = c.Error(middleware.InvalidParameter("id"))
+		return
+	}
+	if err := h.auth.RevokeAPIKey(c.Request.Context(), id); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"apiSorteos/internal/middleware"
+	"apiSorteos/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *APIHandler) ListAudit(c *gin.Context) {
+	filter := models.AuditFilter{
+		Actor:   c.Query("actor"),
+		Action:  c.Query("action"),
+		Outcome: c.Query("outcome"),
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			_ = c.Error(middleware.InvalidParameter("limit"))
+			return
+		}
+		filter.Limit = n
+	}
+	for param, dst := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			_ = c.Error(middleware.ErrInvalidDate.WithField(param, param+" debe tener formato RFC3339"))
+			return
+		}
+		*dst = t
+	}
+
+	entries, err := h.audit.List(c.Request.Context(), filter)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+func (h *APIHandler) VerifyAudit(c *gin.Context) {
+	result, err := h.audit.Verify(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
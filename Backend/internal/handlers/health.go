@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const readinessTimeout = 2 * time.Second
+
+// Healthz responde mientras el proceso esté vivo.
+func (h *APIHandler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, h.health.Live())
+}
+
+// Readyz falla si la base de datos no responde o si se está usando el
+// repositorio de respaldo con INMEMORY_FALLBACK=not-ready.
+func (h *APIHandler) Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	report, ready := h.health.Ready(ctx)
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"net/http"
+
+	"apiSorteos/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *APIHandler) OpenAPI(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", openapi.Spec)
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	"apiSorteos/internal/middleware"
+	"apiSorteos/internal/raffle"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *APIHandler) Present(c *gin.Context) {
+	var cmd raffle.PresenterCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		_ = c.Error(middleware.InvalidBody(err))
+		return
+	}
+	evt, err := h.service.Present(c.Request.Context(), cmd)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, evt)
+}
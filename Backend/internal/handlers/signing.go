@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"net/http"
+
+	"apiSorteos/internal/middleware"
+	"apiSorteos/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *APIHandler) GetSigningKey(c *gin.Context) {
+	signer := h.service.Signer()
+	c.JSON(http.StatusOK, gin.H{
+		"algorithm":    "Ed25519",
+		"raffle":       signer.Raffle(),
+		"publicKey":    base64.StdEncoding.EncodeToString(signer.PublicKey()),
+		"signedFields": []string{"raffle", "person", "prize", "awardedAt", "seed"},
+	})
+}
+
+func (h *APIHandler) VerifyResult(c *gin.Context) {
+	var record models.WinnerRecord
+	if err := c.ShouldBindJSON(&record); err != nil {
+		_ = c.Error(middleware.InvalidBody(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"valid": h.service.Signer().Verify(record)})
+}
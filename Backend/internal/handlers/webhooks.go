@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"apiSorteos/internal/middleware"
+	"apiSorteos/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *APIHandler) CreateWebhook(c *gin.Context) {
+	var payload struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		_ = c.Error(middleware.InvalidBody(err))
+		return
+	}
+	hook, secret, err := h.webhooks.Create(c.Request.Context(), payload.URL, payload.Events, middleware.CurrentPrincipal(c).Subject)
+	if err != nil {
+		var meta interface{}
+		if errors.Is(err, webhooks.ErrInvalidEvent) {
+			meta = gin.H{"knownEvents": webhooks.KnownEvents}
+		}
+		_ = c.Error(err).SetMeta(meta)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"webhook": hook, "secret": secret})
+}
+
+func (h *APIHandler) ListWebhooks(c *gin.Context) {
+	hooks, err := h.webhooks.List(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, hooks)
+}
+
+func (h *APIHandler) DeleteWebhook(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		_ = c.Error(middleware.InvalidParameter("id"))
+		return
+	}
+	if err := h.webhooks.Delete(c.Request.Context(), id); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *APIHandler) ListWebhookDeliveries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		_ = c.Error(middleware.InvalidParameter("id"))
+		return
+	}
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			_ = c.Error(middleware.InvalidParameter("limit"))
+			return
+		}
+	}
+	deliveries, err := h.webhooks.Deliveries(c.Request.Context(), id, limit)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
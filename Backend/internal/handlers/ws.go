@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"apiSorteos/internal/apperr"
+	"apiSorteos/internal/audit"
+	"apiSorteos/internal/i18n"
+	"apiSorteos/internal/middleware"
+	"apiSorteos/internal/models"
+	"apiSorteos/internal/raffle"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteWait  = 10 * time.Second
+	wsMaxMessage = 4 << 10
+)
+
+// newUpgrader acepta el mismo origen que sirve la API y los orígenes que
+// permite la política CORS. Los clientes que no son navegadores no envían
+// Origin y se aceptan siempre.
+func newUpgrader(cors middleware.CORSOptions) *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				return true
+			}
+			if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+				return true
+			}
+			return cors.AllowsOrigin(origin)
+		},
+	}
+}
+
+// SetCORS aplica la política CORS al origen de las conexiones WebSocket.
+func (h *APIHandler) SetCORS(opts middleware.CORSOptions) {
+	h.upgrader = newUpgrader(opts)
+}
+
+// wsCommand es un mensaje del cliente. "auth" debe enviarse antes que
+// cualquier comando administrativo ("spin", "pause", "resume", "present").
+type wsCommand struct {
+	Type          string `json:"type"`
+	Token         string `json:"token,omitempty"`
+	ParticipantID int    `json:"participantId,omitempty"`
+	PrizeID       int    `json:"prizeId,omitempty"`
+	// Presenter solo se usa con "present".
+	Presenter *raffle.PresenterCommand `json:"presenter,omitempty"`
+}
+
+type wsReply struct {
+	Command string      `json:"command"`
+	Result  interface{} `json:"result,omitempty"`
+	Error   string      `json:"error,omitempty"`
+	Code    string      `json:"code,omitempty"`
+}
+
+// WebSocket transmite los mismos eventos que /events y además acepta
+// comandos de administradores autenticados sobre la misma conexión.
+func (h *APIHandler) WebSocket(c *gin.Context) {
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	client := h.service.RegisterClient(lastEventID(c))
+	defer h.service.UnregisterClient(client)
+
+	replies := make(chan raffle.Event, 4)
+	go h.readCommands(ctx, cancel, c, conn, replies)
+
+	heartbeat := time.NewTicker(raffle.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		var msg raffle.Event
+		select {
+		case evt, ok := <-client.Chan():
+			if !ok {
+				return
+			}
+			msg = evt
+		case <-client.Resync():
+			msg = h.service.ResyncEvent(ctx, client)
+		case msg = <-replies:
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+			continue
+		case <-ctx.Done():
+			return
+		}
+
+		_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		if err := conn.WriteJSON(localizeEvent(msg, middleware.CurrentLanguage(c))); err != nil {
+			return
+		}
+	}
+}
+
+func (h *APIHandler) readCommands(ctx context.Context, cancel context.CancelFunc, c *gin.Context, conn *websocket.Conn, replies chan<- raffle.Event) {
+	defer cancel()
+
+	conn.SetReadLimit(wsMaxMessage)
+	extend := func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * raffle.HeartbeatInterval))
+	}
+	_ = extend("")
+	conn.SetPongHandler(extend)
+
+	var credential string
+	lang := middleware.CurrentLanguage(c)
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = extend("")
+
+		var cmd wsCommand
+		reply := raffle.Event{Type: "ack"}
+		if err := json.Unmarshal(data, &cmd); err != nil {
+			reply = raffle.Event{Type: "nack", Data: wsReply{Error: i18n.Message(lang, middleware.ErrInvalidMessage), Code: middleware.ErrInvalidMessage.Code}}
+		} else {
+			result, err := h.runCommand(ctx, c, &credential, cmd)
+			if err != nil {
+				reply = raffle.Event{Type: "nack", Data: wsReply{Command: cmd.Type, Error: i18n.Message(lang, err), Code: apperr.Code(err)}}
+			} else {
+				reply.Data = wsReply{Command: cmd.Type, Result: result}
+			}
+		}
+
+		select {
+		case replies <- reply:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// runCommand guarda la credencial de "auth" en lugar del principal: cada
+// comando administrativo la vuelve a validar, así una sesión cerrada o una
+// clave revocada deja de servir aunque el socket siga abierto.
+func (h *APIHandler) runCommand(ctx context.Context, c *gin.Context, credential *string, cmd wsCommand) (interface{}, error) {
+	switch cmd.Type {
+	case "auth":
+		p, err := h.auth.Authenticate(ctx, "Bearer "+cmd.Token)
+		if err != nil {
+			*credential = ""
+			return nil, middleware.ErrUnauthorized
+		}
+		*credential = cmd.Token
+		return gin.H{"subject": p.Subject}, nil
+	case "spin":
+		principal, err := h.wsPrincipal(ctx, credential)
+		if err != nil || !principal.Allows(raffle.ScopeSpin) {
+			return nil, middleware.ErrUnauthorized
+		}
+		record, err := h.service.RegisterSpin(ctx, cmd.ParticipantID, cmd.PrizeID)
+		h.observeSpin(err)
+		h.auditCommand(ctx, c, principal, "raffle.spin", cmd, err)
+		if err != nil {
+			return nil, err
+		}
+		return record, nil
+	case "pause", "resume":
+		principal, err := h.wsPrincipal(ctx, credential)
+		if err != nil || !principal.Allows(raffle.ScopeSpin) {
+			return nil, middleware.ErrUnauthorized
+		}
+		if cmd.Type == "pause" {
+			h.service.Pause(ctx)
+		} else {
+			h.service.Resume(ctx)
+		}
+		h.auditCommand(ctx, c, principal, "raffle."+cmd.Type, cmd, nil)
+		return nil, nil
+	case "present":
+		principal, err := h.wsPrincipal(ctx, credential)
+		if err != nil || principal.Kind != raffle.PrincipalSession {
+			return nil, middleware.ErrUnauthorized
+		}
+		if cmd.Presenter == nil {
+			return nil, raffle.ErrUnknownPresenterAction
+		}
+		evt, err := h.service.Present(ctx, *cmd.Presenter)
+		h.auditCommand(ctx, c, principal, "presenter."+cmd.Presenter.Action, cmd, err)
+		if err != nil {
+			return nil, err
+		}
+		return evt, nil
+	case "snapshot":
+		// Lo piden los clientes que recibieron un state-patch con otra versión base.
+		return h.service.State(ctx)
+	default:
+		return nil, middleware.ErrUnknownCommand
+	}
+}
+
+// wsPrincipal valida de nuevo la credencial del socket y la olvida si ya no
+// es válida, para que el cliente tenga que enviar "auth" otra vez.
+func (h *APIHandler) wsPrincipal(ctx context.Context, credential *string) (raffle.Principal, error) {
+	if *credential == "" {
+		return raffle.Principal{}, middleware.ErrUnauthorized
+	}
+	p, err := h.auth.Authenticate(ctx, "Bearer "+*credential)
+	if err != nil {
+		*credential = ""
+		return raffle.Principal{}, err
+	}
+	return p, nil
+}
+
+func (h *APIHandler) auditCommand(ctx context.Context, c *gin.Context, principal raffle.Principal, action string, cmd wsCommand, err error) {
+	cmd.Token = ""
+	payload, _ := json.Marshal(cmd)
+	entry := models.AuditEntry{
+		Actor:     principal.Subject,
+		Action:    action,
+		Payload:   payload,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Outcome:   audit.OutcomeSuccess,
+		Status:    http.StatusOK,
+	}
+	if err != nil {
+		entry.Outcome = audit.OutcomeFailure
+		entry.Status = middleware.StatusOf(err)
+	}
+	if _, err := h.audit.Record(context.WithoutCancel(ctx), entry); err != nil {
+		slog.ErrorContext(ctx, "No se pudo registrar la acción en la bitácora", "action", action, "error", err)
+	}
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"apiSorteos/internal/audit"
+	"apiSorteos/internal/eventbus"
+	"apiSorteos/internal/health"
+	"apiSorteos/internal/metrics"
+	"apiSorteos/internal/middleware"
+	"apiSorteos/internal/models"
+	"apiSorteos/internal/raffle"
+	"apiSorteos/internal/repository"
+	"apiSorteos/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func TestUpgraderWithoutAllowListAcceptsSameOriginOnly(t *testing.T) {
+	check := newUpgrader(middleware.CORSOptions{}).CheckOrigin
+	for origin, want := range map[string]bool{
+		"":                            true,
+		"http://api.example.com":      true,
+		"https://sorteo.example.com":  false,
+		"https://intruso.example.com": false,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "http://api.example.com/api/v1/ws", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if got := check(req); got != want {
+			t.Errorf("CheckOrigin(%q) = %v, se esperaba %v", origin, got, want)
+		}
+	}
+}
+
+func TestUpgraderCheckOrigin(t *testing.T) {
+	check := newUpgrader(middleware.CORSOptions{
+		AllowedOrigins: []string{"https://sorteo.example.com"},
+	}).CheckOrigin
+
+	tests := []struct {
+		name   string
+		origin string
+		want   bool
+	}{
+		{"sin origen", "", true},
+		{"mismo origen", "http://api.example.com", true},
+		{"origen permitido", "https://sorteo.example.com", true},
+		{"origen no permitido", "https://intruso.example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://api.example.com/api/v1/ws", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if got := check(req); got != tt.want {
+				t.Errorf("CheckOrigin(%q) = %v, se esperaba %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+type wsMessage struct {
+	ID   uint64          `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// newWSServer levanta /api/v1/ws con el repositorio en memoria y devuelve la
+// URL ws:// junto con el servicio y un token de sesión válido.
+func newWSServer(t *testing.T) (string, *raffle.Service, *raffle.AuthService, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	repo := repository.NewInMemoryRepository()
+	signer, err := raffle.NewSigner("Sorteo de prueba", bytes.Repeat([]byte{3}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	service, err := raffle.NewService(repo, signer, eventbus.NewInProcess())
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := raffle.NewAuthService(raffle.AuthConfig{
+		Users:  map[string]string{"admin": "navidad2024"},
+		Secret: []byte("secreto-de-prueba"),
+		TTL:    time.Hour,
+	}, repo)
+	checker := health.NewChecker(health.Backend{Name: "inmemory"}, repo, false, service.ClientCount, "test")
+	h := NewAPIHandler(service, auth, audit.NewLogger(repo), webhooks.NewDispatcher(repo), checker, metrics.New())
+
+	engine := gin.New()
+	engine.Use(middleware.Language("es"), middleware.Errors())
+	engine.GET("/api/v1/ws", h.WebSocket)
+	srv := httptest.NewServer(engine)
+	t.Cleanup(srv.Close)
+
+	token, err := auth.Login(context.Background(), "admin", "navidad2024", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/v1/ws", service, auth, token
+}
+
+// wsClient guarda los eventos que llegan antes de una respuesta: el servidor
+// puede escribir el ack y los eventos que provocó el comando en cualquier orden.
+type wsClient struct {
+	t       *testing.T
+	conn    *websocket.Conn
+	pending []wsMessage
+}
+
+func dialWS(t *testing.T, url string) *wsClient {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &wsClient{t: t, conn: conn}
+}
+
+func (c *wsClient) read() wsMessage {
+	c.t.Helper()
+	_ = c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg wsMessage
+	if err := c.conn.ReadJSON(&msg); err != nil {
+		c.t.Fatalf("lectura del socket: %v", err)
+	}
+	return msg
+}
+
+// until devuelve el primer mensaje del tipo pedido y descarta los anteriores.
+func (c *wsClient) until(msgType string) wsMessage {
+	c.t.Helper()
+	for len(c.pending) > 0 {
+		msg := c.pending[0]
+		c.pending = c.pending[1:]
+		if msg.Type == msgType {
+			return msg
+		}
+	}
+	for {
+		if msg := c.read(); msg.Type == msgType {
+			return msg
+		}
+	}
+}
+
+// send envía un comando y devuelve su ack o nack; los eventos intermedios
+// quedan disponibles para until.
+func (c *wsClient) send(cmd string) (wsReply, bool) {
+	c.t.Helper()
+	if err := c.conn.WriteMessage(websocket.TextMessage, []byte(cmd)); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		msg := c.read()
+		if msg.Type != "ack" && msg.Type != "nack" {
+			c.pending = append(c.pending, msg)
+			continue
+		}
+		var reply wsReply
+		if err := json.Unmarshal(msg.Data, &reply); err != nil {
+			c.t.Fatal(err)
+		}
+		return reply, msg.Type == "ack"
+	}
+}
+
+func TestWebSocketStateAndBroadcast(t *testing.T) {
+	url, service, _, _ := newWSServer(t)
+	ws := dialWS(t, url)
+
+	first := ws.read()
+	var state models.RaffleState
+	if first.Type != "state" || json.Unmarshal(first.Data, &state) != nil || state.RemainingPeople != 5 {
+		t.Fatalf("primer mensaje = %s %s, se esperaba el estado", first.Type, first.Data)
+	}
+
+	service.Pause(context.Background())
+	if msg := ws.until("paused"); msg.ID == 0 {
+		t.Error("el evento difundido llegó sin id")
+	}
+}
+
+func TestWebSocketCommandsRequireCredential(t *testing.T) {
+	url, service, auth, token := newWSServer(t)
+	ctx := context.Background()
+	_, importKey, err := auth.CreateAPIKey(ctx, "importador", []string{raffle.ScopeParticipants}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws := dialWS(t, url)
+	ws.until("state")
+
+	tests := []struct {
+		cmd string
+		ack bool
+	}{
+		{`{"type":"pause"}`, false},
+		{`{"type":"spin","participantId":1,"prizeId":1}`, false},
+		{`{"type":"auth","token":"no-es-un-token"}`, false},
+		{`{"type":"pause"}`, false},
+		// La clave es válida, pero su alcance no permite girar ni pausar.
+		{`{"type":"auth","token":"` + importKey + `"}`, true},
+		{`{"type":"spin","participantId":1,"prizeId":1}`, false},
+		{`{"type":"pause"}`, false},
+	}
+	for _, tt := range tests {
+		reply, ack := ws.send(tt.cmd)
+		if ack != tt.ack || (!ack && reply.Code != middleware.ErrUnauthorized.Code) {
+			t.Errorf("%s = %v %+v, se esperaba ack=%v", tt.cmd, ack, reply, tt.ack)
+		}
+	}
+	if state, _ := service.State(ctx); state.Paused || state.RemainingPrizes != 5 {
+		t.Fatalf("un comando rechazado cambió el sorteo: %+v", state)
+	}
+
+	if reply, ack := ws.send(`{"type":"auth","token":"` + token + `"}`); !ack {
+		t.Fatalf("auth con sesión = %+v", reply)
+	}
+	if reply, ack := ws.send(`{"type":"spin","participantId":1,"prizeId":1}`); !ack || reply.Command != "spin" {
+		t.Fatalf("spin = %+v", reply)
+	}
+	ws.until("spin-complete")
+	if reply, ack := ws.send(`{"type":"pause"}`); !ack {
+		t.Fatalf("pause = %+v", reply)
+	}
+	ws.until("paused")
+
+	// Cerrar la sesión invalida la credencial del socket en el siguiente comando.
+	if err := auth.Logout(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+	if reply, ack := ws.send(`{"type":"resume"}`); ack || reply.Code != middleware.ErrUnauthorized.Code {
+		t.Errorf("resume tras logout = %v %+v", ack, reply)
+	}
+}
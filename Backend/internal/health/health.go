@@ -0,0 +1,125 @@
+package health
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusNotReady = "not-ready"
+)
+
+// Pinger lo implementan los repositorios para comprobar la conexión.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Backend describe el repositorio activo. Fallback indica que se pidió SQL
+// Server pero no estaba disponible y se usan los datos de ejemplo en memoria.
+type Backend struct {
+	Name     string `json:"name"`
+	Fallback bool   `json:"fallback"`
+}
+
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuiltAt   string `json:"builtAt,omitempty"`
+	GoVersion string `json:"goVersion"`
+}
+
+type DatabaseReport struct {
+	OK        bool    `json:"ok"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status     string          `json:"status"`
+	Backend    Backend         `json:"backend"`
+	Database   *DatabaseReport `json:"database,omitempty"`
+	SSEClients int             `json:"sseClients"`
+	Uptime     string          `json:"uptime"`
+	Build      BuildInfo       `json:"build"`
+}
+
+type Checker struct {
+	backend Backend
+	pinger  Pinger
+	// fallbackNotReady hace que /readyz falle mientras se use el repositorio de respaldo.
+	fallbackNotReady bool
+	clients          func() int
+	started          time.Time
+	build            BuildInfo
+}
+
+func NewChecker(backend Backend, pinger Pinger, fallbackNotReady bool, clients func() int, version string) *Checker {
+	return &Checker{
+		backend:          backend,
+		pinger:           pinger,
+		fallbackNotReady: fallbackNotReady,
+		clients:          clients,
+		started:          time.Now(),
+		build:            readBuildInfo(version),
+	}
+}
+
+// Live no toca la base de datos: solo confirma que el proceso responde.
+func (c *Checker) Live() Report {
+	return c.report(StatusOK)
+}
+
+// Ready comprueba la conexión al repositorio y la política de respaldo.
+func (c *Checker) Ready(ctx context.Context) (Report, bool) {
+	started := time.Now()
+	err := c.pinger.Ping(ctx)
+	db := &DatabaseReport{
+		OK:        err == nil,
+		LatencyMs: float64(time.Since(started).Microseconds()) / 1000,
+	}
+	if err != nil {
+		db.Error = err.Error()
+	}
+
+	status := StatusOK
+	switch {
+	case err != nil, c.backend.Fallback && c.fallbackNotReady:
+		status = StatusNotReady
+	case c.backend.Fallback:
+		status = StatusDegraded
+	}
+	report := c.report(status)
+	report.Database = db
+	return report, status != StatusNotReady
+}
+
+func (c *Checker) report(status string) Report {
+	return Report{
+		Status:     status,
+		Backend:    c.backend,
+		SSEClients: c.clients(),
+		Uptime:     time.Since(c.started).Round(time.Second).String(),
+		Build:      c.build,
+	}
+}
+
+func readBuildInfo(version string) BuildInfo {
+	build := BuildInfo{Version: version}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return build
+	}
+	build.GoVersion = info.GoVersion
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.Commit = setting.Value
+		case "vcs.time":
+			build.BuiltAt = setting.Value
+		}
+	}
+	return build
+}
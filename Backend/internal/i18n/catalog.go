@@ -0,0 +1,183 @@
+package i18n
+
+// catalog traduce cada código de error estable. Al agregar un error de
+// dominio nuevo hay que registrarlo aquí en ambos idiomas.
+var catalog = map[string]map[Lang]string{
+	"INTERNAL": {
+		Spanish: "error interno del servidor",
+		English: "internal server error",
+	},
+	"INVALID_BODY": {
+		Spanish: "cuerpo inválido",
+		English: "invalid request body",
+	},
+	"INVALID_PARAMETER": {
+		Spanish: "{field} inválido",
+		English: "invalid {field}",
+	},
+	"INVALID_DATE": {
+		Spanish: "{field} debe tener formato RFC3339",
+		English: "{field} must use RFC3339 format",
+	},
+	"UNAUTHORIZED": {
+		Spanish: "No autorizado",
+		English: "Unauthorized",
+	},
+	"UNKNOWN_COMMAND": {
+		Spanish: "comando desconocido",
+		English: "unknown command",
+	},
+	"INVALID_MESSAGE": {
+		Spanish: "mensaje inválido",
+		English: "invalid message",
+	},
+	"UNSUPPORTED_API_VERSION": {
+		Spanish: "versión de API no soportada",
+		English: "unsupported API version",
+	},
+	"RATE_LIMITED": {
+		Spanish: "demasiadas peticiones, intenta de nuevo en unos segundos",
+		English: "too many requests, try again in a few seconds",
+	},
+	"TOO_MANY_STREAMS": {
+		Spanish: "demasiadas conexiones de eventos abiertas desde esta dirección",
+		English: "too many event connections open from this address",
+	},
+	"PAYLOAD_TOO_LARGE": {
+		Spanish: "el cuerpo de la petición es demasiado grande",
+		English: "the request body is too large",
+	},
+
+	"NO_PARTICIPANTS": {
+		Spanish: "no hay personas disponibles",
+		English: "there are no participants available",
+	},
+	"NO_PRIZES": {
+		Spanish: "no hay premios disponibles",
+		English: "there are no prizes available",
+	},
+	"PARTICIPANT_USED": {
+		Spanish: "el participante seleccionado ya no está disponible",
+		English: "the selected participant is no longer available",
+	},
+	"PRIZE_UNAVAILABLE": {
+		Spanish: "el premio solicitado ya no está disponible",
+		English: "the requested prize is no longer available",
+	},
+	"NOTHING_TO_REGISTER": {
+		Spanish: "no se pudo registrar el premio porque faltan datos",
+		English: "the award could not be registered because data is missing",
+	},
+	"INVALID_RECENT_WINNERS_LIMIT": {
+		Spanish: "el límite de ganadores recientes no es válido",
+		English: "the recent winners limit is not valid",
+	},
+	"INVALID_PARTICIPANT": {
+		Spanish: "cada participante necesita nombre y email",
+		English: "every participant needs a name and an email",
+	},
+	"INVALID_PARTICIPANT_STATUS": {
+		Spanish: "estado de participante desconocido",
+		English: "unknown participant status",
+	},
+	"VOID_REASON_REQUIRED": {
+		Spanish: "indica el motivo de la anulación",
+		English: "a reason for voiding the award is required",
+	},
+	"AWARD_NOT_FOUND": {
+		Spanish: "el premio entregado no existe o ya fue anulado",
+		English: "the award does not exist or was already voided",
+	},
+	"RAFFLE_PAUSED": {
+		Spanish: "el sorteo está en pausa",
+		English: "the raffle is paused",
+	},
+	"SHUTTING_DOWN": {
+		Spanish: "el servidor se está reiniciando",
+		English: "the server is restarting",
+	},
+
+	"UNKNOWN_PRESENTER_ACTION": {
+		Spanish: "acción de presentación desconocida",
+		English: "unknown presenter action",
+	},
+	"INVALID_COUNTDOWN": {
+		Spanish: "la cuenta regresiva debe durar entre 1 y 60 segundos",
+		English: "the countdown must last between 1 and 60 seconds",
+	},
+	"WINNER_NOT_FOUND": {
+		Spanish: "no hay un ganador para mostrar",
+		English: "there is no winner to show",
+	},
+	"INVALID_SIGNING_KEY": {
+		Spanish: "la clave de firma debe ser una semilla Ed25519 de 32 bytes",
+		English: "the signing key must be a 32-byte Ed25519 seed",
+	},
+
+	"INVALID_CREDENTIALS": {
+		Spanish: "contraseña inválida",
+		English: "invalid password",
+	},
+	"INVALID_TOKEN": {
+		Spanish: "token inválido",
+		English: "invalid token",
+	},
+	"TOKEN_EXPIRED": {
+		Spanish: "la sesión expiró",
+		English: "the session expired",
+	},
+	"TOKEN_REVOKED": {
+		Spanish: "la sesión fue revocada",
+		English: "the session was revoked",
+	},
+	"MISSING_CREDENTIALS": {
+		Spanish: "falta la cabecera Authorization",
+		English: "the Authorization header is missing",
+	},
+	"FORBIDDEN_SCOPE": {
+		Spanish: "la credencial no tiene acceso a esta ruta",
+		English: "the credential has no access to this route",
+	},
+	"TOTP_REQUIRED": {
+		Spanish: "se requiere el código de doble factor",
+		English: "a two-factor code is required",
+	},
+	"INVALID_TOTP": {
+		Spanish: "código de doble factor inválido",
+		English: "invalid two-factor code",
+	},
+	"TOTP_ALREADY_ENABLED": {
+		Spanish: "el doble factor ya está activo",
+		English: "two-factor authentication is already enabled",
+	},
+	"TOTP_NOT_ENROLLED": {
+		Spanish: "el usuario no tiene doble factor configurado",
+		English: "the user has no two-factor authentication set up",
+	},
+
+	"INVALID_SCOPE": {
+		Spanish: "alcance de clave de API desconocido",
+		English: "unknown API key scope",
+	},
+	"API_KEY_NAME_REQUIRED": {
+		Spanish: "la clave de API necesita un nombre",
+		English: "the API key needs a name",
+	},
+	"API_KEY_NOT_FOUND": {
+		Spanish: "la clave de API no existe o fue revocada",
+		English: "the API key does not exist or was revoked",
+	},
+
+	"INVALID_WEBHOOK_URL": {
+		Spanish: "la URL del webhook debe ser http o https",
+		English: "the webhook URL must be http or https",
+	},
+	"INVALID_WEBHOOK_EVENT": {
+		Spanish: "tipo de evento de webhook desconocido",
+		English: "unknown webhook event type",
+	},
+	"WEBHOOK_NOT_FOUND": {
+		Spanish: "el webhook no existe o fue eliminado",
+		English: "the webhook does not exist or was deleted",
+	},
+}
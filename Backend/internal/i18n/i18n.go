@@ -0,0 +1,87 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"apiSorteos/internal/apperr"
+)
+
+type Lang string
+
+const (
+	Spanish Lang = "es"
+	English Lang = "en"
+)
+
+// Default es el idioma de los mensajes cuando no se configura otro.
+const Default = Spanish
+
+// Parse acepta etiquetas como "en", "en-US" o "es_EC" y devuelve el idioma
+// soportado que les corresponde.
+func Parse(tag string) (Lang, bool) {
+	base := strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(base, "-_"); i >= 0 {
+		base = base[:i]
+	}
+	switch Lang(base) {
+	case Spanish, English:
+		return Lang(base), true
+	default:
+		return "", false
+	}
+}
+
+// Negotiate elige el idioma soportado con mayor peso en una cabecera
+// Accept-Language; si ninguno aplica devuelve fallback.
+func Negotiate(acceptLanguage string, fallback Lang) Lang {
+	type candidate struct {
+		lang Lang
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		lang, ok := Parse(tag)
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{lang, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return fallback
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang
+}
+
+// Text devuelve el mensaje del catálogo para code, o fallback si el código
+// no está traducido. {field} se reemplaza por field.
+func Text(lang Lang, code, field, fallback string) string {
+	template, ok := catalog[code][lang]
+	if !ok {
+		return fallback
+	}
+	return strings.ReplaceAll(template, "{field}", field)
+}
+
+// Message traduce un error de dominio. Los errores sin código se muestran
+// como error interno para no filtrar detalles de la infraestructura.
+func Message(lang Lang, err error) string {
+	appErr, ok := apperr.As(err)
+	if !ok {
+		return Text(lang, apperr.CodeInternal, "", err.Error())
+	}
+	return Text(lang, appErr.Code, appErr.Field, appErr.Message)
+}
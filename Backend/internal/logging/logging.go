@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID guarda el id de la petición para que los logs de servicios y
+// repositorios lo incluyan al usar las variantes *Context de slog.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+type Options struct {
+	// Level acepta debug, info, warn o error.
+	Level string
+	// Path activa además la escritura en archivo con rotación; vacío solo usa stdout.
+	Path       string
+	MaxSizeMB  int
+	MaxBackups int
+}
+
+// Setup instala un logger JSON como predeterminado. También redirige el
+// paquete log, de modo que las dependencias que lo usen salen en el mismo formato.
+func Setup(opts Options) (*slog.Logger, io.Closer, error) {
+	var (
+		out    io.Writer = os.Stdout
+		closer io.Closer = nopCloser{}
+	)
+	if opts.Path != "" {
+		file, err := NewRotatingWriter(opts.Path, int64(opts.MaxSizeMB)<<20, opts.MaxBackups)
+		if err != nil {
+			return nil, nil, err
+		}
+		out = io.MultiWriter(os.Stdout, file)
+		closer = file
+	}
+
+	handler := slog.NewJSONHandler(out, &slog.HandlerOptions{Level: parseLevel(opts.Level)})
+	logger := slog.New(contextHandler{handler})
+	slog.SetDefault(logger)
+	return logger, closer, nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// contextHandler añade requestId a cada registro emitido con un contexto que lo tenga.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("requestId", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
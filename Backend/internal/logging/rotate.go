@@ -0,0 +1,135 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// RotatingWriter escribe en "<base>_<fecha><ext>" y abre un archivo nuevo al
+// cambiar el día o al superar maxSize. Los archivos del mismo día se numeran
+// "<base>_<fecha>.1<ext>", ".2", etc. Si maxBackups > 0 se borran los más viejos.
+type RotatingWriter struct {
+	mu         sync.Mutex
+	base       string
+	ext        string
+	maxSize    int64
+	maxBackups int
+
+	file *os.File
+	day  string
+	seq  int
+	size int64
+}
+
+func NewRotatingWriter(path string, maxSize int64, maxBackups int) (*RotatingWriter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("no se pudo crear la carpeta de logs: %w", err)
+	}
+	ext := filepath.Ext(path)
+	w := &RotatingWriter{
+		base:       strings.TrimSuffix(path, ext),
+		ext:        ext,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if err := w.open(time.Now(), 0); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	switch {
+	case now.Format(dateLayout) != w.day:
+		if err := w.rotate(now, 0); err != nil {
+			return 0, err
+		}
+	case w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize:
+		// El archivo actual puede estar por debajo del límite; hay que pasar
+		// al siguiente número para no reabrir el mismo.
+		if err := w.rotate(now, w.seq+1); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+func (w *RotatingWriter) rotate(now time.Time, fromSeq int) error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	if err := w.open(now, fromSeq); err != nil {
+		return err
+	}
+	w.prune()
+	return nil
+}
+
+// open usa el primer archivo del día, desde el número fromSeq, que todavía
+// tenga espacio.
+func (w *RotatingWriter) open(now time.Time, fromSeq int) error {
+	day := now.Format(dateLayout)
+	for seq := fromSeq; ; seq++ {
+		name := fmt.Sprintf("%s_%s%s", w.base, day, w.ext)
+		if seq > 0 {
+			name = fmt.Sprintf("%s_%s.%d%s", w.base, day, seq, w.ext)
+		}
+		info, err := os.Stat(name)
+		if err == nil && w.maxSize > 0 && info.Size() >= w.maxSize {
+			continue
+		}
+		file, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		stat, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return err
+		}
+		w.file, w.day, w.seq, w.size = file, day, seq, stat.Size()
+		return nil
+	}
+}
+
+func (w *RotatingWriter) prune() {
+	if w.maxBackups <= 0 {
+		return
+	}
+	matches, err := filepath.Glob(w.base + "_*" + w.ext)
+	if err != nil {
+		return
+	}
+	// Del más viejo al más nuevo.
+	sort.Slice(matches, func(i, j int) bool {
+		a, _ := os.Stat(matches[i])
+		b, _ := os.Stat(matches[j])
+		return a != nil && b != nil && a.ModTime().Before(b.ModTime())
+	})
+	current := w.file.Name()
+	for len(matches) > w.maxBackups+1 {
+		if matches[0] != current {
+			os.Remove(matches[0])
+		}
+		matches = matches[1:]
+	}
+}
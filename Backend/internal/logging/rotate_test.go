@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingWriterAdvancesOnSize(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotatingWriter(filepath.Join(dir, "api.log"), 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	line := []byte(strings.Repeat("x", 59) + "\n")
+	for i := 0; i < 5; i++ {
+		if _, err := w.Write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	day := time.Now().Format(dateLayout)
+	for seq := 0; seq < 5; seq++ {
+		name := filepath.Join(dir, fmt.Sprintf("api_%s.%d.log", day, seq))
+		if seq == 0 {
+			name = filepath.Join(dir, fmt.Sprintf("api_%s.log", day))
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("falta %s: %v", filepath.Base(name), err)
+		}
+		if info.Size() != int64(len(line)) {
+			t.Errorf("%s pesa %d bytes, se esperaban %d", filepath.Base(name), info.Size(), len(line))
+		}
+	}
+}
+
+func TestRotatingWriterReusesFileWithRoom(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "api.log")
+	day := time.Now().Format(dateLayout)
+	full := filepath.Join(dir, fmt.Sprintf("api_%s.log", day))
+	if err := os.WriteFile(full, []byte(strings.Repeat("x", 100)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewRotatingWriter(path, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("hola\n")); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	// Al reiniciar se sigue escribiendo en el primer archivo con espacio.
+	w, err = NewRotatingWriter(path, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, err := w.Write([]byte("chau\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("api_%s.1.log", day)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "hola\nchau\n" {
+		t.Errorf("contenido = %q", content)
+	}
+}
+
+func TestRotatingWriterPrunesBackups(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotatingWriter(filepath.Join(dir, "api.log"), 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	for i := 0; i < 6; i++ {
+		if _, err := w.Write([]byte("012345678\n")); err != nil {
+			t.Fatal(err)
+		}
+		// prune ordena por fecha de modificación.
+		time.Sleep(10 * time.Millisecond)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "api_*.log"))
+	if len(matches) != 3 {
+		t.Errorf("quedaron %d archivos, se esperaban 3: %v", len(matches), matches)
+	}
+}
@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "sorteos"
+
+// Resultados de giros y logins.
+const (
+	SpinSuccess  = "success"
+	SpinConflict = "conflict"
+	SpinError    = "error"
+
+	LoginSuccess      = "success"
+	LoginFailure      = "failure"
+	LoginTOTPRequired = "totp_required"
+	LoginError        = "error"
+)
+
+// Metrics agrupa los colectores del servidor en un registro propio, para no
+// mezclarlos con los de otras librerías que usen el registro global.
+type Metrics struct {
+	registry     *prometheus.Registry
+	spins        *prometheus.CounterVec
+	logins       *prometheus.CounterVec
+	repoLatency  *prometheus.HistogramVec
+	httpDuration *prometheus.HistogramVec
+}
+
+// Stats es lo que se lee de raffle.Service en cada scrape.
+type Stats interface {
+	ClientCount() int
+	DroppedEvents() uint64
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		spins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "spins_total",
+			Help:      "Giros registrados por resultado.",
+		}, []string{"result"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "login_attempts_total",
+			Help:      "Intentos de login por resultado.",
+		}, []string{"result"}),
+		repoLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_query_duration_seconds",
+			Help:      "Duración de las llamadas al repositorio por método.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"method"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duración de las peticiones HTTP por ruta.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.spins,
+		m.logins,
+		m.repoLatency,
+		m.httpDuration,
+	)
+	return m
+}
+
+// WatchService registra las métricas que se leen del servicio. Se separa de
+// New porque el servicio se crea con el repositorio ya instrumentado.
+func (m *Metrics) WatchService(stats Stats) {
+	m.registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "sse_connected_clients",
+			Help:      "Clientes SSE y WebSocket conectados a esta réplica.",
+		}, func() float64 { return float64(stats.ClientCount()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "dropped_events_total",
+			Help:      "Eventos que no cupieron en el buffer de un cliente lento.",
+		}, func() float64 { return float64(stats.DroppedEvents()) }),
+	)
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) ObserveSpin(result string) {
+	m.spins.WithLabelValues(result).Inc()
+}
+
+func (m *Metrics) ObserveLogin(result string) {
+	m.logins.WithLabelValues(result).Inc()
+}
+
+// Middleware mide cada petición usando la ruta registrada en gin, no la URL,
+// para que los parámetros no disparen la cantidad de series.
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		m.httpDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(started).Seconds())
+	}
+}
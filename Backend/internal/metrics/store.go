@@ -0,0 +1,199 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"apiSorteos/internal/apperr"
+	"apiSorteos/internal/models"
+	"apiSorteos/internal/repository"
+)
+
+// instrumentedStore mide la latencia de cada método del repositorio.
+type instrumentedStore struct {
+	next    repository.Store
+	metrics *Metrics
+}
+
+func InstrumentStore(store repository.Store, m *Metrics) repository.Store {
+	return &instrumentedStore{next: store, metrics: m}
+}
+
+// observe registra la latencia y deja la consulta en el log con el contexto
+// de la petición, para que lleve su requestId. Los errores de dominio son
+// respuestas esperadas y no se reportan como fallos.
+func (s *instrumentedStore) observe(ctx context.Context, method string, started time.Time, errp *error) {
+	elapsed := time.Since(started)
+	s.metrics.repoLatency.WithLabelValues(method).Observe(elapsed.Seconds())
+
+	err := *errp
+	switch _, domain := apperr.As(err); {
+	case err == nil:
+		slog.DebugContext(ctx, "Consulta al repositorio", "method", method, "durationMs", elapsed.Milliseconds())
+	case domain:
+		slog.InfoContext(ctx, "Consulta al repositorio rechazada", "method", method, "durationMs", elapsed.Milliseconds(), "code", apperr.Code(err))
+	default:
+		slog.ErrorContext(ctx, "Falló la consulta al repositorio", "method", method, "durationMs", elapsed.Milliseconds(), "error", err)
+	}
+}
+
+func (s *instrumentedStore) Ping(ctx context.Context) (err error) {
+	defer s.observe(ctx, "Ping", time.Now(), &err)
+	return s.next.Ping(ctx)
+}
+
+func (s *instrumentedStore) ListParticipants(ctx context.Context) (_ []models.Person, err error) {
+	defer s.observe(ctx, "ListParticipants", time.Now(), &err)
+	return s.next.ListParticipants(ctx)
+}
+
+func (s *instrumentedStore) SearchParticipants(ctx context.Context, filter models.ParticipantFilter) (_ []models.Person, _ int, err error) {
+	defer s.observe(ctx, "SearchParticipants", time.Now(), &err)
+	return s.next.SearchParticipants(ctx, filter)
+}
+
+func (s *instrumentedStore) GetParticipant(ctx context.Context, id int) (_ models.Person, err error) {
+	defer s.observe(ctx, "GetParticipant", time.Now(), &err)
+	return s.next.GetParticipant(ctx, id)
+}
+
+func (s *instrumentedStore) ListPrizes(ctx context.Context) (_ []models.Prize, err error) {
+	defer s.observe(ctx, "ListPrizes", time.Now(), &err)
+	return s.next.ListPrizes(ctx)
+}
+
+func (s *instrumentedStore) ListRecentWinners(ctx context.Context, limit int) (_ []models.WinnerRecord, err error) {
+	defer s.observe(ctx, "ListRecentWinners", time.Now(), &err)
+	return s.next.ListRecentWinners(ctx, limit)
+}
+
+func (s *instrumentedStore) GetWinner(ctx context.Context, id int) (_ models.WinnerRecord, err error) {
+	defer s.observe(ctx, "GetWinner", time.Now(), &err)
+	return s.next.GetWinner(ctx, id)
+}
+
+func (s *instrumentedStore) SaveAward(ctx context.Context, participantID, prizeID int, seal func(models.WinnerRecord) (models.WinnerRecord, error)) (_ models.WinnerRecord, err error) {
+	defer s.observe(ctx, "SaveAward", time.Now(), &err)
+	return s.next.SaveAward(ctx, participantID, prizeID, seal)
+}
+
+func (s *instrumentedStore) VoidAward(ctx context.Context, winnerID int, reason, voidedBy string) (_ models.VoidedAward, err error) {
+	defer s.observe(ctx, "VoidAward", time.Now(), &err)
+	return s.next.VoidAward(ctx, winnerID, reason, voidedBy)
+}
+
+func (s *instrumentedStore) UpsertParticipants(ctx context.Context, people []models.Person) (_, _ int, err error) {
+	defer s.observe(ctx, "UpsertParticipants", time.Now(), &err)
+	return s.next.UpsertParticipants(ctx, people)
+}
+
+func (s *instrumentedStore) RevokeSession(ctx context.Context, sessionID string, expiresAt time.Time) (err error) {
+	defer s.observe(ctx, "RevokeSession", time.Now(), &err)
+	return s.next.RevokeSession(ctx, sessionID, expiresAt)
+}
+
+func (s *instrumentedStore) RevokeSessionsBefore(ctx context.Context, cutoff time.Time) (err error) {
+	defer s.observe(ctx, "RevokeSessionsBefore", time.Now(), &err)
+	return s.next.RevokeSessionsBefore(ctx, cutoff)
+}
+
+func (s *instrumentedStore) IsSessionRevoked(ctx context.Context, sessionID string, issuedAt time.Time) (_ bool, err error) {
+	defer s.observe(ctx, "IsSessionRevoked", time.Now(), &err)
+	return s.next.IsSessionRevoked(ctx, sessionID, issuedAt)
+}
+
+func (s *instrumentedStore) GetTOTP(ctx context.Context, username string) (_ models.TOTPEnrollment, err error) {
+	defer s.observe(ctx, "GetTOTP", time.Now(), &err)
+	return s.next.GetTOTP(ctx, username)
+}
+
+func (s *instrumentedStore) SaveTOTP(ctx context.Context, enrollment models.TOTPEnrollment) (err error) {
+	defer s.observe(ctx, "SaveTOTP", time.Now(), &err)
+	return s.next.SaveTOTP(ctx, enrollment)
+}
+
+func (s *instrumentedStore) DeleteTOTP(ctx context.Context, username string) (err error) {
+	defer s.observe(ctx, "DeleteTOTP", time.Now(), &err)
+	return s.next.DeleteTOTP(ctx, username)
+}
+
+func (s *instrumentedStore) MarkTOTPStepUsed(ctx context.Context, username string, step int64) (_ bool, err error) {
+	defer s.observe(ctx, "MarkTOTPStepUsed", time.Now(), &err)
+	return s.next.MarkTOTPStepUsed(ctx, username, step)
+}
+
+func (s *instrumentedStore) ReplaceRecoveryCodes(ctx context.Context, username string, codeHashes []string) (err error) {
+	defer s.observe(ctx, "ReplaceRecoveryCodes", time.Now(), &err)
+	return s.next.ReplaceRecoveryCodes(ctx, username, codeHashes)
+}
+
+func (s *instrumentedStore) ConsumeRecoveryCode(ctx context.Context, username, codeHash string) (_ bool, err error) {
+	defer s.observe(ctx, "ConsumeRecoveryCode", time.Now(), &err)
+	return s.next.ConsumeRecoveryCode(ctx, username, codeHash)
+}
+
+func (s *instrumentedStore) CreateAPIKey(ctx context.Context, key models.APIKey) (_ models.APIKey, err error) {
+	defer s.observe(ctx, "CreateAPIKey", time.Now(), &err)
+	return s.next.CreateAPIKey(ctx, key)
+}
+
+func (s *instrumentedStore) ListAPIKeys(ctx context.Context) (_ []models.APIKey, err error) {
+	defer s.observe(ctx, "ListAPIKeys", time.Now(), &err)
+	return s.next.ListAPIKeys(ctx)
+}
+
+func (s *instrumentedStore) RevokeAPIKey(ctx context.Context, id int) (err error) {
+	defer s.observe(ctx, "RevokeAPIKey", time.Now(), &err)
+	return s.next.RevokeAPIKey(ctx, id)
+}
+
+func (s *instrumentedStore) FindAPIKeyByHash(ctx context.Context, hash string) (_ models.APIKey, err error) {
+	defer s.observe(ctx, "FindAPIKeyByHash", time.Now(), &err)
+	return s.next.FindAPIKeyByHash(ctx, hash)
+}
+
+func (s *instrumentedStore) TouchAPIKey(ctx context.Context, id int, usedAt time.Time) (err error) {
+	defer s.observe(ctx, "TouchAPIKey", time.Now(), &err)
+	return s.next.TouchAPIKey(ctx, id, usedAt)
+}
+
+func (s *instrumentedStore) AppendAudit(ctx context.Context, seal func(prevHash string) models.AuditEntry) (_ models.AuditEntry, err error) {
+	defer s.observe(ctx, "AppendAudit", time.Now(), &err)
+	return s.next.AppendAudit(ctx, seal)
+}
+
+func (s *instrumentedStore) ListAudit(ctx context.Context, filter models.AuditFilter) (_ []models.AuditEntry, err error) {
+	defer s.observe(ctx, "ListAudit", time.Now(), &err)
+	return s.next.ListAudit(ctx, filter)
+}
+
+func (s *instrumentedStore) ListAuditChain(ctx context.Context, afterID int64, limit int) (_ []models.AuditEntry, err error) {
+	defer s.observe(ctx, "ListAuditChain", time.Now(), &err)
+	return s.next.ListAuditChain(ctx, afterID, limit)
+}
+
+func (s *instrumentedStore) CreateWebhook(ctx context.Context, hook models.Webhook) (_ models.Webhook, err error) {
+	defer s.observe(ctx, "CreateWebhook", time.Now(), &err)
+	return s.next.CreateWebhook(ctx, hook)
+}
+
+func (s *instrumentedStore) ListWebhooks(ctx context.Context) (_ []models.Webhook, err error) {
+	defer s.observe(ctx, "ListWebhooks", time.Now(), &err)
+	return s.next.ListWebhooks(ctx)
+}
+
+func (s *instrumentedStore) DeleteWebhook(ctx context.Context, id int) (err error) {
+	defer s.observe(ctx, "DeleteWebhook", time.Now(), &err)
+	return s.next.DeleteWebhook(ctx, id)
+}
+
+func (s *instrumentedStore) AppendWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) (err error) {
+	defer s.observe(ctx, "AppendWebhookDelivery", time.Now(), &err)
+	return s.next.AppendWebhookDelivery(ctx, delivery)
+}
+
+func (s *instrumentedStore) ListWebhookDeliveries(ctx context.Context, webhookID, limit int) (_ []models.WebhookDelivery, err error) {
+	defer s.observe(ctx, "ListWebhookDeliveries", time.Now(), &err)
+	return s.next.ListWebhookDeliveries(ctx, webhookID, limit)
+}
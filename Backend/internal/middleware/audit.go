@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"apiSorteos/internal/audit"
+	"apiSorteos/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxAuditBody  = 64 << 10
+	auditActorKey = "auditActor"
+)
+
+// Audit registra la petición en la bitácora una vez atendida, tanto si tuvo
+// éxito como si falló. Si se coloca antes de RequireScope también quedan
+// registrados los intentos rechazados por credenciales inválidas.
+func Audit(logger *audit.Logger, action string) gin.HandlerFunc {
+	return auditRequest(logger, action, true)
+}
+
+// AuditLogin registra los intentos de login sin guardar el cuerpo, que lleva
+// la contraseña aunque venga mal formado: solo usuario, IP y resultado.
+func AuditLogin(logger *audit.Logger) gin.HandlerFunc {
+	return auditRequest(logger, "auth.login", false)
+}
+
+func auditRequest(logger *audit.Logger, action string, captureBody bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var (
+			body      []byte
+			truncated bool
+		)
+		if captureBody {
+			body, truncated = peekBody(c)
+		}
+
+		c.Next()
+		// Errors() respondería más tarde; se adelanta para auditar el estado real.
+		writeProblem(c)
+
+		payload := audit.Redact(body)
+		switch {
+		case !captureBody:
+			payload = nil
+			if username := c.GetString(auditActorKey); username != "" {
+				payload, _ = json.Marshal(map[string]string{"username": username})
+			}
+		case truncated:
+			payload = json.RawMessage(`{"truncated":true}`)
+		case len(body) == 0 && len(c.Params) > 0:
+			params := map[string]string{}
+			for _, p := range c.Params {
+				params[p.Key] = p.Value
+			}
+			payload, _ = json.Marshal(params)
+		}
+
+		outcome := audit.OutcomeSuccess
+		if c.Writer.Status() >= http.StatusBadRequest {
+			outcome = audit.OutcomeFailure
+		}
+
+		entry := models.AuditEntry{
+			Actor:     auditActor(c),
+			Action:    action,
+			Payload:   payload,
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			Outcome:   outcome,
+			Status:    c.Writer.Status(),
+		}
+		// El registro no debe perderse si el cliente cerró la conexión.
+		if _, err := logger.Record(context.WithoutCancel(c.Request.Context()), entry); err != nil {
+			slog.ErrorContext(c.Request.Context(), "No se pudo registrar la acción en la bitácora", "action", action, "error", err)
+		}
+	}
+}
+
+func peekBody(c *gin.Context) ([]byte, bool) {
+	if c.Request.Body == nil {
+		return nil, false
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxAuditBody+1))
+	if err != nil {
+		return nil, false
+	}
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+	if len(body) > maxAuditBody {
+		return nil, true
+	}
+	return body, false
+}
+
+// SetAuditActor permite a los handlers sin credencial previa, como el login,
+// indicar quién realizó la acción.
+func SetAuditActor(c *gin.Context, actor string) {
+	c.Set(auditActorKey, actor)
+}
+
+func auditActor(c *gin.Context) string {
+	if subject := CurrentPrincipal(c).Subject; subject != "" {
+		return subject
+	}
+	if actor := c.GetString(auditActorKey); actor != "" {
+		return actor
+	}
+	return "anónimo"
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"apiSorteos/internal/audit"
+	"apiSorteos/internal/models"
+	"apiSorteos/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuditLoginNeverStoresTheBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := audit.NewLogger(repository.NewInMemoryRepository())
+
+	router := gin.New()
+	router.Use(Errors())
+	router.POST("/auth/login", AuditLogin(logger), func(c *gin.Context) {
+		var payload struct {
+			Username string `json:"username"`
+		}
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			_ = c.Error(InvalidBody(err))
+			return
+		}
+		SetAuditActor(c, payload.Username)
+		_ = c.Error(ErrUnauthorized)
+	})
+
+	tests := []struct {
+		name    string
+		body    string
+		status  int
+		actor   string
+		payload string
+	}{
+		{"JSON válido", `{"username":"admin","password":"secreto-123"}`, http.StatusUnauthorized, "admin", `{"username":"admin"}`},
+		{"cuerpo mal formado", `username=admin&password=secreto-123`, http.StatusBadRequest, "anónimo", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, se esperaba %d", rec.Code, tt.status)
+			}
+
+			entries, err := logger.List(context.Background(), models.AuditFilter{Action: "auth.login", Limit: 1})
+			if err != nil || len(entries) == 0 {
+				t.Fatalf("no se registró el intento: %v", err)
+			}
+			entry := entries[0]
+			if strings.Contains(string(entry.Payload), "secreto") {
+				t.Errorf("la bitácora guardó la contraseña: %s", entry.Payload)
+			}
+			if string(entry.Payload) != tt.payload {
+				t.Errorf("payload = %s, se esperaba %s", entry.Payload, tt.payload)
+			}
+			if entry.Actor != tt.actor || entry.Outcome != audit.OutcomeFailure || entry.Status != tt.status || entry.IP == "" {
+				t.Errorf("entrada = %+v", entry)
+			}
+		})
+	}
+}
+
+func TestAuditRedactsSensitiveFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := audit.NewLogger(repository.NewInMemoryRepository())
+
+	router := gin.New()
+	router.POST("/keys", Audit(logger, "apikey.create"), func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	req := httptest.NewRequest(http.MethodPost, "/keys", strings.NewReader(`{"name":"bot","password":"secreto-123"}`))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries, err := logger.List(context.Background(), models.AuditFilter{Action: "apikey.create", Limit: 1})
+	if err != nil || len(entries) == 0 {
+		t.Fatalf("no se registró la acción: %v", err)
+	}
+	var payload map[string]string
+	if err := json.Unmarshal(entries[0].Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload["name"] != "bot" || payload["password"] != "***" {
+		t.Errorf("payload = %v", payload)
+	}
+}
+
+func TestAuditRecordsSocketIPUnlessProxyIsTrusted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		proxies []string
+		ip      string
+	}{
+		{"sin proxies de confianza", nil, "192.0.2.1"},
+		{"proxy de confianza", []string{"192.0.2.1"}, "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := audit.NewLogger(repository.NewInMemoryRepository())
+			router := gin.New()
+			if err := router.SetTrustedProxies(tt.proxies); err != nil {
+				t.Fatal(err)
+			}
+			router.POST("/winners/:id/void", Audit(logger, "award.void"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			// httptest.NewRequest usa 192.0.2.1 como dirección remota.
+			req := httptest.NewRequest(http.MethodPost, "/winners/1/void", strings.NewReader(`{"reason":"duplicado"}`))
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			entries, err := logger.List(context.Background(), models.AuditFilter{Action: "award.void", Limit: 1})
+			if err != nil || len(entries) == 0 {
+				t.Fatalf("no se registró la anulación: %v", err)
+			}
+			if entries[0].IP != tt.ip {
+				t.Errorf("IP = %q, se esperaba %q", entries[0].IP, tt.ip)
+			}
+		})
+	}
+}
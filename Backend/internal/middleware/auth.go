@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"apiSorteos/internal/raffle"
+
+	"github.com/gin-gonic/gin"
+)
+
+const principalKey = "principal"
+
+// RequireSession solo deja pasar a anfitriones con sesión iniciada.
+func RequireSession(auth *raffle.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := authenticate(c, auth)
+		if !ok {
+			return
+		}
+		if principal.Kind != raffle.PrincipalSession {
+			Fail(c, raffle.ErrForbiddenScope)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireScope acepta sesiones de anfitrión o claves de API con el alcance indicado.
+func RequireScope(auth *raffle.AuthService, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := authenticate(c, auth)
+		if !ok {
+			return
+		}
+		if !principal.Allows(scope) {
+			Fail(c, raffle.ErrForbiddenScope)
+			return
+		}
+		c.Next()
+	}
+}
+
+// CurrentPrincipal devuelve la credencial validada por RequireSession o RequireScope.
+func CurrentPrincipal(c *gin.Context) raffle.Principal {
+	value, _ := c.Get(principalKey)
+	principal, _ := value.(raffle.Principal)
+	return principal
+}
+
+func authenticate(c *gin.Context, auth *raffle.AuthService) (raffle.Principal, bool) {
+	principal, err := auth.Authenticate(c.Request.Context(), c.GetHeader("Authorization"))
+	if err != nil {
+		Fail(c, ErrUnauthorized)
+		return raffle.Principal{}, false
+	}
+	c.Set(principalKey, principal)
+	return principal, true
+}
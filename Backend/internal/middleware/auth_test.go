@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"apiSorteos/internal/raffle"
+	"apiSorteos/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireScopeCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	repo := repository.NewInMemoryRepository()
+	auth := raffle.NewAuthService(raffle.AuthConfig{
+		Users:  map[string]string{"admin": "navidad2024"},
+		Secret: []byte("secreto-de-prueba"),
+		TTL:    time.Hour,
+	}, repo)
+
+	session, err := auth.Login(ctx, "admin", "navidad2024", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey := func(name string, scopes ...string) string {
+		t.Helper()
+		key, plain, err := auth.CreateAPIKey(ctx, name, scopes, "admin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Solo se guarda el sha256 de la clave, nunca la clave en claro.
+		sum := sha256.Sum256([]byte(plain))
+		if key.Hash != hex.EncodeToString(sum[:]) || strings.Contains(key.Hash, plain) || !strings.HasPrefix(plain, key.Prefix+"_") {
+			t.Fatalf("clave guardada = %+v", key)
+		}
+		return plain
+	}
+	spinKey := newKey("bot", raffle.ScopeSpin)
+	importKey := newKey("importador", raffle.ScopeParticipants)
+	revokedKey := newKey("vieja", raffle.ScopeSpin)
+	keys, err := auth.ListAPIKeys(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := auth.RevokeAPIKey(ctx, keys[len(keys)-1].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.Use(Errors())
+	whoami := func(c *gin.Context) { c.String(http.StatusOK, CurrentPrincipal(c).Subject) }
+	router.POST("/spin", RequireScope(auth, raffle.ScopeSpin), whoami)
+	router.GET("/keys", RequireSession(auth), whoami)
+
+	// Cambiar el último carácter deja el prefijo intacto pero altera el hash.
+	last := "A"
+	if strings.HasSuffix(spinKey, last) {
+		last = "B"
+	}
+	tampered := spinKey[:len(spinKey)-1] + last
+
+	tests := []struct {
+		name    string
+		path    string
+		header  string
+		status  int
+		subject string
+	}{
+		{"sin credencial", "/spin", "", http.StatusUnauthorized, ""},
+		{"sesión con Bearer", "/spin", "Bearer " + session, http.StatusOK, "admin"},
+		{"clave con ApiKey", "/spin", "ApiKey " + spinKey, http.StatusOK, "apikey:bot"},
+		{"clave con Bearer y prefijo sk_", "/spin", "Bearer " + spinKey, http.StatusOK, "apikey:bot"},
+		{"esquema en minúsculas", "/spin", "apikey " + spinKey, http.StatusOK, "apikey:bot"},
+		{"clave alterada", "/spin", "ApiKey " + tampered, http.StatusUnauthorized, ""},
+		{"token de sesión como ApiKey", "/spin", "ApiKey " + session, http.StatusUnauthorized, ""},
+		{"Bearer inválido", "/spin", "Bearer abc.def", http.StatusUnauthorized, ""},
+		{"esquema desconocido", "/spin", "Basic " + spinKey, http.StatusUnauthorized, ""},
+		{"clave revocada", "/spin", "ApiKey " + revokedKey, http.StatusUnauthorized, ""},
+		{"clave sin el alcance", "/spin", "ApiKey " + importKey, http.StatusForbidden, ""},
+		{"ruta solo para sesiones", "/keys", "ApiKey " + spinKey, http.StatusForbidden, ""},
+		{"sesión en ruta de sesiones", "/keys", "Bearer " + session, http.StatusOK, "admin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := http.MethodPost
+			if tt.path == "/keys" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, se esperaba %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			switch tt.status {
+			case http.StatusOK:
+				if rec.Body.String() != tt.subject {
+					t.Errorf("principal = %q, se esperaba %q", rec.Body, tt.subject)
+				}
+			case http.StatusForbidden:
+				if code := problemCode(t, rec.Body.Bytes()); code != raffle.ErrForbiddenScope.Code {
+					t.Errorf("code = %s", code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSOptions describe qué orígenes pueden usar la API desde un navegador.
+// Sin AllowedOrigins solo el mismo origen puede leer las respuestas. "*"
+// acepta cualquier origen; con AllowCredentials se responde con el origen
+// concreto porque los navegadores rechazan "*".
+type CORSOptions struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// AllowsOrigin indica si origin está en AllowedOrigins o si se acepta cualquiera.
+func (o CORSOptions) AllowsOrigin(origin string) bool {
+	return slices.Contains(o.AllowedOrigins, "*") || slices.Contains(o.AllowedOrigins, origin)
+}
+
+// CORS responde las consultas previas (OPTIONS) y añade las cabeceras CORS a
+// las peticiones de orígenes permitidos. A los demás no les añade nada y es
+// el navegador quien bloquea la respuesta.
+func CORS(opts CORSOptions) gin.HandlerFunc {
+	methods := strings.Join(opts.AllowedMethods, ", ")
+	headers := strings.Join(opts.AllowedHeaders, ", ")
+	exposed := strings.Join(opts.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(opts.MaxAge.Seconds()))
+	anyOrigin := slices.Contains(opts.AllowedOrigins, "*")
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Origin")
+		if !opts.AllowsOrigin(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusNoContent)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if anyOrigin && !opts.AllowCredentials {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if opts.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			c.Header("Access-Control-Allow-Methods", methods)
+			c.Header("Access-Control-Allow-Headers", headers)
+			if opts.MaxAge > 0 {
+				c.Header("Access-Control-Max-Age", maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		if exposed != "" {
+			c.Header("Access-Control-Expose-Headers", exposed)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	allowList := CORSOptions{
+		AllowedOrigins: []string{"https://sorteo.example.com"},
+		AllowedMethods: []string{http.MethodGet, http.MethodPost},
+		AllowedHeaders: []string{"Content-Type", "Authorization"},
+		ExposedHeaders: []string{RequestIDHeader},
+		MaxAge:         10 * time.Minute,
+	}
+	wildcard := allowList
+	wildcard.AllowedOrigins = []string{"*"}
+	credentials := wildcard
+	credentials.AllowCredentials = true
+	sameOrigin := allowList
+	sameOrigin.AllowedOrigins = nil
+
+	tests := []struct {
+		name      string
+		opts      CORSOptions
+		method    string
+		origin    string
+		preflight bool
+		status    int
+		headers   map[string]string
+	}{
+		{
+			name:   "comodín",
+			opts:   wildcard,
+			method: http.MethodGet,
+			origin: "https://otro.example.com",
+			status: http.StatusOK,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":      "*",
+				"Access-Control-Allow-Credentials": "",
+				"Access-Control-Expose-Headers":    RequestIDHeader,
+			},
+		},
+		{
+			name:   "origen en la lista",
+			opts:   allowList,
+			method: http.MethodGet,
+			origin: "https://sorteo.example.com",
+			status: http.StatusOK,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin": "https://sorteo.example.com",
+				"Vary":                        "Origin",
+			},
+		},
+		{
+			name:   "origen fuera de la lista",
+			opts:   allowList,
+			method: http.MethodGet,
+			origin: "https://intruso.example.com",
+			status: http.StatusOK,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":   "",
+				"Access-Control-Expose-Headers": "",
+			},
+		},
+		{
+			name:   "credenciales devuelven el origen y nunca *",
+			opts:   credentials,
+			method: http.MethodGet,
+			origin: "https://otro.example.com",
+			status: http.StatusOK,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":      "https://otro.example.com",
+				"Access-Control-Allow-Credentials": "true",
+			},
+		},
+		{
+			name:      "consulta previa con max-age",
+			opts:      allowList,
+			method:    http.MethodOptions,
+			origin:    "https://sorteo.example.com",
+			preflight: true,
+			status:    http.StatusNoContent,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":  "https://sorteo.example.com",
+				"Access-Control-Allow-Methods": "GET, POST",
+				"Access-Control-Allow-Headers": "Content-Type, Authorization",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			name:      "consulta previa de un origen no permitido",
+			opts:      allowList,
+			method:    http.MethodOptions,
+			origin:    "https://intruso.example.com",
+			preflight: true,
+			status:    http.StatusNoContent,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":  "",
+				"Access-Control-Allow-Methods": "",
+				"Access-Control-Max-Age":       "",
+			},
+		},
+		{
+			name:   "sin lista solo el mismo origen",
+			opts:   sameOrigin,
+			method: http.MethodGet,
+			origin: "https://otro.example.com",
+			status: http.StatusOK,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":   "",
+				"Access-Control-Expose-Headers": "",
+			},
+		},
+		{
+			name:      "consulta previa sin lista",
+			opts:      sameOrigin,
+			method:    http.MethodOptions,
+			origin:    "https://otro.example.com",
+			preflight: true,
+			status:    http.StatusNoContent,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":  "",
+				"Access-Control-Allow-Methods": "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(CORS(tt.opts))
+			router.Any("/api/v1/state", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(tt.method, "/api/v1/state", nil)
+			req.Header.Set("Origin", tt.origin)
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, se esperaba %d", rec.Code, tt.status)
+			}
+			for name, want := range tt.headers {
+				if got := rec.Header().Get(name); got != want {
+					t.Errorf("%s = %q, se esperaba %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCORSWithoutOriginPassesThrough(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CORS(CORSOptions{AllowedOrigins: []string{"*"}}))
+	router.GET("/api/v1/state", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/state", nil))
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q sin cabecera Origin", got)
+	}
+}
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"apiSorteos/internal/apperr"
+	"apiSorteos/internal/i18n"
+	"apiSorteos/internal/raffle"
+	"apiSorteos/internal/repository"
+	"apiSorteos/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+)
+
+const problemContentType = "application/problem+json"
+
+var (
+	ErrInvalidBody      = apperr.New("INVALID_BODY", "cuerpo inválido")
+	ErrInvalidParameter = apperr.New("INVALID_PARAMETER", "parámetro inválido")
+	ErrInvalidDate      = apperr.New("INVALID_DATE", "la fecha debe tener formato RFC3339")
+	ErrUnauthorized     = apperr.New("UNAUTHORIZED", "No autorizado")
+	ErrUnknownCommand   = apperr.New("UNKNOWN_COMMAND", "comando desconocido")
+	ErrInvalidMessage   = apperr.New("INVALID_MESSAGE", "mensaje inválido")
+	errInternal         = apperr.New(apperr.CodeInternal, "error interno del servidor")
+)
+
+// errorStatuses es la única tabla que traduce errores de dominio a códigos
+// HTTP; se recorre en orden con errors.Is.
+var errorStatuses = []struct {
+	err    error
+	status int
+}{
+	{ErrInvalidBody, http.StatusBadRequest},
+	{ErrInvalidParameter, http.StatusBadRequest},
+	{ErrInvalidDate, http.StatusBadRequest},
+	{ErrInvalidMessage, http.StatusBadRequest},
+	{ErrUnknownCommand, http.StatusBadRequest},
+	{ErrUnauthorized, http.StatusUnauthorized},
+	{ErrUnsupportedAPIVersion, http.StatusBadRequest},
+	{ErrRateLimited, http.StatusTooManyRequests},
+	{ErrTooManyStreams, http.StatusTooManyRequests},
+	{ErrPayloadTooLarge, http.StatusRequestEntityTooLarge},
+
+	{raffle.ErrShuttingDown, http.StatusServiceUnavailable},
+	{raffle.ErrPaused, http.StatusConflict},
+	{repository.ErrNoParticipants, http.StatusConflict},
+	{repository.ErrNoPrizes, http.StatusConflict},
+	{repository.ErrPrizeUnavailable, http.StatusConflict},
+	{repository.ErrParticipantUsed, http.StatusConflict},
+	{repository.ErrNothingToRegister, http.StatusConflict},
+	{raffle.ErrInvalidParticipantStatus, http.StatusBadRequest},
+	{repository.ErrInvalidParticipant, http.StatusBadRequest},
+	{repository.ErrRecentWinnersInvalid, http.StatusBadRequest},
+	{raffle.ErrVoidReasonRequired, http.StatusBadRequest},
+	{repository.ErrAwardNotFound, http.StatusNotFound},
+
+	{raffle.ErrUnknownPresenterAction, http.StatusBadRequest},
+	{raffle.ErrInvalidCountdown, http.StatusBadRequest},
+	{raffle.ErrWinnerNotFound, http.StatusNotFound},
+
+	{raffle.ErrTOTPRequired, http.StatusUnauthorized},
+	{raffle.ErrInvalidPassword, http.StatusUnauthorized},
+	{raffle.ErrInvalidTOTP, http.StatusUnauthorized},
+	{raffle.ErrInvalidToken, http.StatusUnauthorized},
+	{raffle.ErrTokenExpired, http.StatusUnauthorized},
+	{raffle.ErrTokenRevoked, http.StatusUnauthorized},
+	{raffle.ErrMissingCredentials, http.StatusUnauthorized},
+	{repository.ErrTOTPNotEnrolled, http.StatusNotFound},
+	{raffle.ErrTOTPAlreadyEnabled, http.StatusConflict},
+	{raffle.ErrForbiddenScope, http.StatusForbidden},
+
+	{raffle.ErrInvalidScope, http.StatusBadRequest},
+	{raffle.ErrAPIKeyNameRequired, http.StatusBadRequest},
+	{repository.ErrAPIKeyNotFound, http.StatusNotFound},
+
+	{webhooks.ErrInvalidURL, http.StatusBadRequest},
+	{webhooks.ErrInvalidEvent, http.StatusBadRequest},
+	{repository.ErrWebhookNotFound, http.StatusNotFound},
+}
+
+// InvalidParameter indica qué parámetro de la URL no se pudo interpretar.
+func InvalidParameter(name string) error {
+	return ErrInvalidParameter.WithField(name, name+" inválido")
+}
+
+// StatusOf devuelve el código HTTP que corresponde a err.
+func StatusOf(err error) int {
+	for _, entry := range errorStatuses {
+		if errors.Is(err, entry.err) {
+			return entry.status
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// Errors convierte el último error registrado con c.Error en una respuesta
+// application/problem+json (RFC 9457), salvo que el handler ya haya respondido.
+// Los campos de gin.H en Meta se añaden al cuerpo como extensiones.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		writeProblem(c)
+	}
+}
+
+// Fail registra el error, responde y corta la cadena. Lo usan los middleware
+// que rechazan la petición antes de llegar al handler.
+func Fail(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+	writeProblem(c)
+}
+
+func writeProblem(c *gin.Context) {
+	last := c.Errors.Last()
+	if last == nil || c.Writer.Written() {
+		return
+	}
+
+	err := last.Err
+	lang := CurrentLanguage(c)
+	status := StatusOf(err)
+	if status == http.StatusInternalServerError {
+		slog.ErrorContext(c.Request.Context(), "Error no controlado", "path", c.Request.URL.Path, "error", err)
+		err = errInternal
+	}
+
+	body := gin.H{
+		"type":     "about:blank",
+		"title":    http.StatusText(status),
+		"status":   status,
+		"detail":   i18n.Message(lang, err),
+		"instance": c.Request.URL.Path,
+		"code":     apperr.Code(err),
+	}
+	if id := c.GetString(requestIDKey); id != "" {
+		body[requestIDKey] = id
+	}
+	if extra, ok := last.Meta.(gin.H); ok {
+		for k, v := range extra {
+			if _, reserved := body[k]; !reserved {
+				body[k] = v
+			}
+		}
+	}
+
+	c.Header("Content-Type", problemContentType)
+	c.Header("Content-Language", string(lang))
+	c.Render(status, render.JSON{Data: body})
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"apiSorteos/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+const languageKey = "language"
+
+// Language elige el idioma de los mensajes a partir de Accept-Language. Si el
+// cliente no pide un idioma soportado se usa el configurado para el sorteo.
+func Language(fallback i18n.Lang) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(languageKey, i18n.Negotiate(c.GetHeader("Accept-Language"), fallback))
+		c.Next()
+	}
+}
+
+// CurrentLanguage devuelve el idioma negociado para la petición.
+func CurrentLanguage(c *gin.Context) i18n.Lang {
+	if lang, ok := c.Value(languageKey).(i18n.Lang); ok {
+		return lang
+	}
+	return i18n.Default
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"apiSorteos/internal/apperr"
+	"apiSorteos/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrRateLimited     = apperr.New("RATE_LIMITED", "demasiadas peticiones, intenta de nuevo en unos segundos")
+	ErrTooManyStreams  = apperr.New("TOO_MANY_STREAMS", "demasiadas conexiones de eventos abiertas desde esta dirección")
+	ErrPayloadTooLarge = apperr.New("PAYLOAD_TOO_LARGE", "el cuerpo de la petición es demasiado grande")
+)
+
+// unlimitedRoutes quedan fuera del límite por IP: los sondeos de
+// orquestadores y Prometheus llegan siempre desde las mismas direcciones.
+var unlimitedRoutes = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+
+// RouteKey identifica una ruta sin importar la versión de la API, por
+// ejemplo "GET /state" tanto para /api/state como para /api/v1/state.
+func RouteKey(c *gin.Context) string {
+	path := c.FullPath()
+	if trimmed, ok := strings.CutPrefix(path, "/api/v1"); ok {
+		path = trimmed
+	} else if trimmed, ok := strings.CutPrefix(path, "/api"); ok {
+		path = trimmed
+	}
+	return c.Request.Method + " " + path
+}
+
+// RateLimit aplica perIP a todas las peticiones de cada IP y, además, el
+// límite de routes que corresponda a la ruta (clave de RouteKey).
+func RateLimit(perIP *ratelimit.Limiter, routes map[string]*ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if unlimitedRoutes[c.FullPath()] {
+			c.Next()
+			return
+		}
+		ip := c.ClientIP()
+		ok, wait := perIP.Allow(ip)
+		if ok {
+			route := RouteKey(c)
+			ok, wait = routes[route].Allow(route + "|" + ip)
+		}
+		if !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			Fail(c, ErrRateLimited)
+			return
+		}
+		c.Next()
+	}
+}
+
+// LimitStreams limita las conexiones SSE o WebSocket abiertas por cada IP.
+func LimitStreams(streams *ratelimit.Counter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+		if !streams.Acquire(ip) {
+			Fail(c, ErrTooManyStreams)
+			return
+		}
+		defer streams.Release(ip)
+		c.Next()
+	}
+}
+
+// BodyLimit corta los cuerpos que superan max bytes; routes permite un
+// límite distinto por ruta, como la importación masiva de participantes.
+func BodyLimit(max int64, routes map[string]int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := max
+		if n, ok := routes[RouteKey(c)]; ok {
+			limit = n
+		}
+		if limit <= 0 || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > limit {
+			Fail(c, ErrPayloadTooLarge)
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// InvalidBody traduce un error al leer el JSON del cuerpo; distingue los
+// cuerpos cortados por BodyLimit de los mal formados.
+func InvalidBody(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return ErrPayloadTooLarge
+	}
+	return ErrInvalidBody
+}
@@ -0,0 +1,225 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"apiSorteos/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+func problemCode(t *testing.T, body []byte) string {
+	t.Helper()
+	var problem struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(body, &problem); err != nil {
+		t.Fatalf("la respuesta no es problem+json: %s", body)
+	}
+	return problem.Code
+}
+
+func TestRateLimitRespondsWithRetryAfter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	routes := map[string]*ratelimit.Limiter{"POST /auth/login": ratelimit.New(ratelimit.Rule{Rate: 0.5, Burst: 1})}
+
+	router := gin.New()
+	router.Use(Errors(), RateLimit(ratelimit.New(ratelimit.Rule{Rate: 100, Burst: 100}), routes))
+	router.POST("/api/v1/auth/login", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/api/v1/state", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/api/v1/auth/login"); rec.Code != http.StatusOK {
+		t.Fatalf("primer login = %d", rec.Code)
+	}
+	rec := do(http.MethodPost, "/api/v1/auth/login")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("segundo login = %d, se esperaba 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, se esperaba 2", got)
+	}
+	if got := rec.Header().Get("Content-Type"); got != problemContentType {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if code := problemCode(t, rec.Body.Bytes()); code != ErrRateLimited.Code {
+		t.Errorf("code = %s", code)
+	}
+
+	// El límite de una ruta no afecta a las demás.
+	if rec := do(http.MethodGet, "/api/v1/state"); rec.Code != http.StatusOK {
+		t.Errorf("state = %d tras agotar el login", rec.Code)
+	}
+}
+
+func TestRateLimitPerIPSkipsProbes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Errors(), RateLimit(ratelimit.New(ratelimit.Rule{Rate: 0.1, Burst: 1}), nil))
+	router.GET("/api/v1/state", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("healthz = %d", rec.Code)
+		}
+	}
+	codes := []int{}
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/state", nil))
+		codes = append(codes, rec.Code)
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+		t.Errorf("estados = %v, se esperaba [200 429]", codes)
+	}
+}
+
+func TestRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	if err := router.SetTrustedProxies(nil); err != nil {
+		t.Fatal(err)
+	}
+	router.Use(Errors(), RateLimit(ratelimit.New(ratelimit.Rule{Rate: 0.1, Burst: 1}), nil))
+	router.GET("/api/v1/state", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	codes := []int{}
+	for _, forwarded := range []string{"203.0.113.1", "203.0.113.2", "203.0.113.3, 10.0.0.1"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/state", nil)
+		req.Header.Set("X-Forwarded-For", forwarded)
+		req.Header.Set("X-Real-IP", forwarded)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests || codes[2] != http.StatusTooManyRequests {
+		t.Errorf("estados = %v, se esperaba [200 429 429]", codes)
+	}
+}
+
+func TestLimitStreamsCapsOpenConnections(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	opened := make(chan struct{})
+	release := make(chan struct{})
+
+	router := gin.New()
+	router.Use(Errors())
+	router.GET("/api/v1/events", LimitStreams(ratelimit.NewCounter(1)), func(c *gin.Context) {
+		opened <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	first := make(chan int)
+	go func() {
+		resp, err := http.Get(srv.URL + "/api/v1/events")
+		if err != nil {
+			first <- 0
+			return
+		}
+		resp.Body.Close()
+		first <- resp.StatusCode
+	}()
+	select {
+	case <-opened:
+	case <-time.After(5 * time.Second):
+		t.Fatal("el primer flujo no se abrió")
+	}
+
+	resp, err := http.Get(srv.URL + "/api/v1/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("segundo flujo = %d, se esperaba 429", resp.StatusCode)
+	}
+	if code := problemCode(t, body); code != ErrTooManyStreams.Code {
+		t.Errorf("code = %s", code)
+	}
+
+	close(release)
+	if status := <-first; status != http.StatusOK {
+		t.Fatalf("primer flujo = %d", status)
+	}
+	// Al cerrarse el primero vuelve a haber lugar.
+	go func() { <-opened }()
+	resp, err = http.Get(srv.URL + "/api/v1/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("flujo tras liberar = %d", resp.StatusCode)
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Errors(), BodyLimit(32, map[string]int64{"PUT /participants": 1024}))
+	bind := func(c *gin.Context) {
+		var payload any
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			_ = c.Error(InvalidBody(err))
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+	router.POST("/api/v1/spin", bind)
+	router.PUT("/api/v1/participants", bind)
+
+	large := `{"participantId":1,"prizeId":2,"extra":"` + strings.Repeat("x", 64) + `"}`
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		chunked bool
+		status  int
+		code    string
+	}{
+		{"dentro del límite", http.MethodPost, "/api/v1/spin", `{"participantId":1}`, false, http.StatusOK, ""},
+		{"Content-Length excedido", http.MethodPost, "/api/v1/spin", large, false, http.StatusRequestEntityTooLarge, ErrPayloadTooLarge.Code},
+		{"chunked excedido", http.MethodPost, "/api/v1/spin", large, true, http.StatusRequestEntityTooLarge, ErrPayloadTooLarge.Code},
+		{"JSON mal formado", http.MethodPost, "/api/v1/spin", `{"participantId":`, false, http.StatusBadRequest, ErrInvalidBody.Code},
+		{"límite propio de la ruta", http.MethodPut, "/api/v1/participants", large, false, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, se esperaba %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.code != "" {
+				if code := problemCode(t, rec.Body.Bytes()); code != tt.code {
+					t.Errorf("code = %s, se esperaba %s", code, tt.code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"apiSorteos/internal/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	APIVersionHeader = "API-Version"
+	// CurrentAPIVersion es el único contrato publicado; las rutas sin versión
+	// responden con él mientras sigan disponibles.
+	CurrentAPIVersion = "1"
+)
+
+var ErrUnsupportedAPIVersion = apperr.New("UNSUPPORTED_API_VERSION", "versión de API no soportada")
+
+// APIVersion fija la versión que atiende el grupo de rutas. La versión se
+// elige por la ruta (/api/v1); si el cliente además envía API-Version, debe
+// coincidir para evitar que hable con un contrato que no espera.
+func APIVersion(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(APIVersionHeader, version)
+		if requested := strings.TrimSpace(c.GetHeader(APIVersionHeader)); requested != "" && requested != version {
+			Fail(c, ErrUnsupportedAPIVersion)
+			return
+		}
+		c.Next()
+	}
+}
+
+// Deprecated marca rutas que serán retiradas. Link apunta a la ruta
+// equivalente bajo successor y Sunset, si se indica, a la fecha de retiro.
+func Deprecated(prefix, successor string, sunset time.Time) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		if !sunset.IsZero() {
+			c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		c.Header("Link", "<"+successor+strings.TrimPrefix(c.Request.URL.Path, prefix)+`>; rel="successor-version"`)
+		c.Next()
+	}
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Person struct {
 	ID    int    `json:"id"`
@@ -19,12 +22,126 @@ type WinnerRecord struct {
 	Person    Person    `json:"person"`
 	Prize     Prize     `json:"prize"`
 	AwardedAt time.Time `json:"awardedAt"`
+	// Seed es un valor aleatorio propio de cada sorteo y Signature la firma
+	// Ed25519 (base64) del resultado emitida por el servidor.
+	Seed      string `json:"seed,omitempty"`
+	Signature string `json:"signature,omitempty"`
 }
 
+// VoidedAward es un premio anulado: la persona vuelve a la espera y el premio
+// queda disponible para otro giro.
+type VoidedAward struct {
+	Winner   WinnerRecord `json:"winner"`
+	Reason   string       `json:"reason"`
+	VoidedBy string       `json:"voidedBy"`
+	VoidedAt time.Time    `json:"voidedAt"`
+}
+
+// Estados por los que se puede filtrar a los participantes.
+const (
+	ParticipantsWaiting = "waiting"
+	ParticipantsAwarded = "awarded"
+	ParticipantsAll     = "all"
+)
+
+// ParticipantFilter busca por nombre o email (Query) dentro de un estado.
+type ParticipantFilter struct {
+	Status string
+	Query  string
+	Offset int
+	Limit  int
+}
+
+type ParticipantPage struct {
+	Items    []Person `json:"items"`
+	Total    int      `json:"total"`
+	Page     int      `json:"page"`
+	PageSize int      `json:"pageSize"`
+}
+
+// RaffleState.WaitingPeople es solo una muestra; la lista completa se pagina
+// en /api/participants y RemainingPeople tiene el total.
 type RaffleState struct {
 	RemainingPeople int            `json:"remainingPeople"`
 	RemainingPrizes int            `json:"remainingPrizes"`
 	RecentWinners   []WinnerRecord `json:"recentWinners"`
 	UpcomingPrizes  []Prize        `json:"upcomingPrizes"`
 	WaitingPeople   []Person       `json:"waitingPeople"`
+	Paused          bool           `json:"paused"`
+	// Version permite aplicar los eventos "state-patch" posteriores.
+	Version uint64 `json:"version"`
+}
+
+// TOTPEnrollment es la configuración de doble factor de un administrador.
+type TOTPEnrollment struct {
+	Username     string    `json:"username"`
+	Secret       string    `json:"-"`
+	Confirmed    bool      `json:"confirmed"`
+	LastUsedStep int64     `json:"-"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// APIKey es una credencial para integraciones sin login humano. Solo se
+// guarda el hash; la clave completa se muestra una única vez al crearla.
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  string     `json:"createdBy"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// AuditEntry es un registro inmutable de una acción administrativa. Hash
+// encadena el registro con PrevHash, el hash del registro anterior.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	IP        string          `json:"ip"`
+	UserAgent string          `json:"userAgent"`
+	Outcome   string          `json:"outcome"`
+	Status    int             `json:"status"`
+	CreatedAt time.Time       `json:"createdAt"`
+	PrevHash  string          `json:"prevHash"`
+	Hash      string          `json:"hash"`
+}
+
+type AuditFilter struct {
+	Actor   string
+	Action  string
+	Outcome string
+	From    time.Time
+	To      time.Time
+	Limit   int
+}
+
+// Webhook es una suscripción de un sistema externo a eventos del sorteo.
+// Secret firma cada envío y solo se muestra al crear la suscripción.
+type Webhook struct {
+	ID        int        `json:"id"`
+	URL       string     `json:"url"`
+	Events    []string   `json:"events"`
+	Secret    string     `json:"-"`
+	CreatedBy string     `json:"createdBy"`
+	CreatedAt time.Time  `json:"createdAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+// WebhookDelivery es un intento de envío; cada reintento queda registrado.
+type WebhookDelivery struct {
+	ID         int64     `json:"id"`
+	WebhookID  int       `json:"webhookId"`
+	EventID    uint64    `json:"eventId"`
+	EventType  string    `json:"eventType"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Success    bool      `json:"success"`
+	DurationMs int64     `json:"durationMs"`
+	CreatedAt  time.Time `json:"createdAt"`
 }
@@ -0,0 +1,9 @@
+package openapi
+
+import _ "embed"
+
+// Spec es el contrato OpenAPI 3 de la API pública. Debe actualizarse junto
+// con los handlers y los modelos que describe.
+//
+//go:embed openapi.json
+var Spec []byte
@@ -0,0 +1,142 @@
+package raffle
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"apiSorteos/internal/apperr"
+	"apiSorteos/internal/models"
+	"apiSorteos/internal/repository"
+)
+
+// Alcances que puede tener una clave de API. Cada uno habilita un grupo de
+// rutas; las rutas de administración solo aceptan sesiones de anfitrión.
+const (
+	ScopeSpin         = "spin"
+	ScopeParticipants = "participants"
+)
+
+var KnownScopes = []string{ScopeSpin, ScopeParticipants}
+
+const apiKeyPrefix = "sk_"
+
+var (
+	ErrMissingCredentials = apperr.New("MISSING_CREDENTIALS", "falta la cabecera Authorization")
+	ErrInvalidScope       = apperr.New("INVALID_SCOPE", "alcance de clave de API desconocido")
+	ErrAPIKeyNameRequired = apperr.New("API_KEY_NAME_REQUIRED", "la clave de API necesita un nombre")
+	ErrForbiddenScope     = apperr.New("FORBIDDEN_SCOPE", "la credencial no tiene acceso a esta ruta")
+)
+
+const (
+	PrincipalSession = "session"
+	PrincipalAPIKey  = "apikey"
+)
+
+// Principal es quien hace la petición: un anfitrión con sesión o una integración con clave de API.
+type Principal struct {
+	Kind    string
+	Subject string
+	Scopes  []string
+}
+
+func (p Principal) Allows(scope string) bool {
+	return p.Kind == PrincipalSession || slices.Contains(p.Scopes, scope)
+}
+
+// Authenticate acepta "Bearer <token de sesión>", "ApiKey <clave>" o
+// "Bearer <clave>" cuando la clave tiene el prefijo sk_.
+func (a *AuthService) Authenticate(ctx context.Context, header string) (Principal, error) {
+	scheme, credential, _ := strings.Cut(strings.TrimSpace(header), " ")
+	credential = strings.TrimSpace(credential)
+	if credential == "" {
+		return Principal{}, ErrMissingCredentials
+	}
+
+	switch {
+	case strings.EqualFold(scheme, "ApiKey"),
+		strings.EqualFold(scheme, "Bearer") && strings.HasPrefix(credential, apiKeyPrefix):
+		return a.authenticateAPIKey(ctx, credential)
+	case strings.EqualFold(scheme, "Bearer"):
+		session, err := a.Validate(ctx, credential)
+		if err != nil {
+			return Principal{}, err
+		}
+		return Principal{Kind: PrincipalSession, Subject: session.Subject}, nil
+	default:
+		return Principal{}, ErrInvalidToken
+	}
+}
+
+func (a *AuthService) authenticateAPIKey(ctx context.Context, key string) (Principal, error) {
+	stored, err := a.store.FindAPIKeyByHash(ctx, hashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return Principal{}, ErrInvalidToken
+		}
+		return Principal{}, err
+	}
+	if err := a.store.TouchAPIKey(ctx, stored.ID, time.Now().UTC()); err != nil {
+		return Principal{}, err
+	}
+	return Principal{Kind: PrincipalAPIKey, Subject: "apikey:" + stored.Name, Scopes: stored.Scopes}, nil
+}
+
+// CreateAPIKey devuelve la clave en claro, que no vuelve a estar disponible.
+func (a *AuthService) CreateAPIKey(ctx context.Context, name string, scopes []string, createdBy string) (models.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return models.APIKey{}, "", ErrAPIKeyNameRequired
+	}
+	if len(scopes) == 0 {
+		return models.APIKey{}, "", ErrInvalidScope
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(KnownScopes, scope) {
+			return models.APIKey{}, "", ErrInvalidScope
+		}
+	}
+
+	id := make([]byte, 4)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return models.APIKey{}, "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return models.APIKey{}, "", err
+	}
+	prefix := apiKeyPrefix + hex.EncodeToString(id)
+	plain := prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	key, err := a.store.CreateAPIKey(ctx, models.APIKey{
+		Name:      name,
+		Prefix:    prefix,
+		Hash:      hashAPIKey(plain),
+		Scopes:    slices.Clone(scopes),
+		CreatedBy: createdBy,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+	return key, plain, nil
+}
+
+func (a *AuthService) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	return a.store.ListAPIKeys(ctx)
+}
+
+func (a *AuthService) RevokeAPIKey(ctx context.Context, id int) error {
+	return a.store.RevokeAPIKey(ctx, id)
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
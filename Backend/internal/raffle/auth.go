@@ -1,32 +1,291 @@
 package raffle
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
-	"fmt"
+	"strings"
 	"time"
+
+	"apiSorteos/internal/apperr"
+	"apiSorteos/internal/models"
+	"apiSorteos/internal/repository"
+
+	qrcode "github.com/skip2/go-qrcode"
 )
 
+var (
+	ErrInvalidPassword    = apperr.New("INVALID_CREDENTIALS", "contraseña inválida")
+	ErrInvalidToken       = apperr.New("INVALID_TOKEN", "token inválido")
+	ErrTokenExpired       = apperr.New("TOKEN_EXPIRED", "la sesión expiró")
+	ErrTokenRevoked       = apperr.New("TOKEN_REVOKED", "la sesión fue revocada")
+	ErrTOTPRequired       = apperr.New("TOTP_REQUIRED", "se requiere el código de doble factor")
+	ErrInvalidTOTP        = apperr.New("INVALID_TOTP", "código de doble factor inválido")
+	ErrTOTPAlreadyEnabled = apperr.New("TOTP_ALREADY_ENABLED", "el doble factor ya está activo")
+)
+
+const recoveryCodeCount = 10
+
+type AuthConfig struct {
+	// Users asocia cada usuario administrador con su contraseña.
+	Users  map[string]string
+	Secret []byte
+	TTL    time.Duration
+	// Issuer es el nombre que muestran las apps de autenticación.
+	Issuer string
+}
+
+// AuthStore es la persistencia que necesita AuthService.
+type AuthStore interface {
+	repository.SessionStore
+	repository.TOTPStore
+	repository.APIKeyStore
+}
+
 type AuthService struct {
-	adminPassword string
-	token         string
+	users  map[string]string
+	secret []byte
+	ttl    time.Duration
+	issuer string
+	store  AuthStore
+}
+
+// TOTPSetup es lo que necesita una app de autenticación para registrar la cuenta.
+type TOTPSetup struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauthUri"`
+	QRCode     string `json:"qrCode"`
+}
+
+// Session son los datos firmados dentro del token del anfitrión.
+type Session struct {
+	ID        string    `json:"jti"`
+	Subject   string    `json:"sub"`
+	IssuedAt  time.Time `json:"iat"`
+	ExpiresAt time.Time `json:"exp"`
+}
+
+func NewAuthService(cfg AuthConfig, store AuthStore) *AuthService {
+	return &AuthService{
+		users:  cfg.Users,
+		secret: cfg.Secret,
+		ttl:    cfg.TTL,
+		issuer: cfg.Issuer,
+		store:  store,
+	}
+}
+
+// Login valida usuario y contraseña y, si el usuario activó el doble factor,
+// exige un código TOTP vigente o uno de sus códigos de recuperación.
+func (a *AuthService) Login(ctx context.Context, username, password, code string) (string, error) {
+	expected, ok := a.users[username]
+	if subtle.ConstantTimeCompare([]byte(password), []byte(expected)) != 1 || !ok {
+		return "", ErrInvalidPassword
+	}
+
+	enrollment, err := a.store.GetTOTP(ctx, username)
+	switch {
+	case errors.Is(err, repository.ErrTOTPNotEnrolled):
+	case err != nil:
+		return "", err
+	case enrollment.Confirmed:
+		if code == "" {
+			return "", ErrTOTPRequired
+		}
+		if err := a.checkSecondFactor(ctx, enrollment, code); err != nil {
+			return "", err
+		}
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	return a.sign(Session{
+		ID:        hex.EncodeToString(id),
+		Subject:   username,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(a.ttl),
+	})
+}
+
+func (a *AuthService) Validate(ctx context.Context, token string) (Session, error) {
+	session, err := a.parse(token)
+	if err != nil {
+		return Session{}, err
+	}
+	if time.Now().UTC().After(session.ExpiresAt) {
+		return Session{}, ErrTokenExpired
+	}
+	revoked, err := a.store.IsSessionRevoked(ctx, session.ID, session.IssuedAt)
+	if err != nil {
+		return Session{}, err
+	}
+	if revoked {
+		return Session{}, ErrTokenRevoked
+	}
+	return session, nil
+}
+
+// Logout revoca solo la sesión del token recibido.
+func (a *AuthService) Logout(ctx context.Context, token string) error {
+	session, err := a.Validate(ctx, token)
+	if err != nil {
+		return err
+	}
+	return a.store.RevokeSession(ctx, session.ID, session.ExpiresAt)
+}
+
+// RevokeAll invalida todas las sesiones emitidas hasta este momento.
+func (a *AuthService) RevokeAll(ctx context.Context) error {
+	return a.store.RevokeSessionsBefore(ctx, time.Now().UTC())
 }
 
-func NewAuthService(password string) *AuthService {
-	return &AuthService{adminPassword: password}
+// BeginTOTPEnrollment genera un secreto nuevo pendiente de confirmación.
+func (a *AuthService) BeginTOTPEnrollment(ctx context.Context, username string) (TOTPSetup, error) {
+	current, err := a.store.GetTOTP(ctx, username)
+	if err != nil && !errors.Is(err, repository.ErrTOTPNotEnrolled) {
+		return TOTPSetup{}, err
+	}
+	if err == nil && current.Confirmed {
+		return TOTPSetup{}, ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return TOTPSetup{}, err
+	}
+	if err := a.store.SaveTOTP(ctx, models.TOTPEnrollment{
+		Username:  username,
+		Secret:    secret,
+		CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		return TOTPSetup{}, err
+	}
+
+	uri := totpURI(a.issuer, username, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		return TOTPSetup{}, err
+	}
+	return TOTPSetup{
+		Secret:     secret,
+		OTPAuthURI: uri,
+		QRCode:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// ConfirmTOTP activa el doble factor con el primer código de la app y
+// devuelve los códigos de recuperación, que solo se muestran esta vez.
+func (a *AuthService) ConfirmTOTP(ctx context.Context, username, code string) ([]string, error) {
+	enrollment, err := a.store.GetTOTP(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment.Confirmed {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	step, ok := matchTOTP(enrollment.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTOTP
+	}
+
+	codes, err := newRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, c := range codes {
+		hashes[i] = hashRecoveryCode(c)
+	}
+
+	enrollment.Confirmed = true
+	enrollment.LastUsedStep = step
+	if err := a.store.SaveTOTP(ctx, enrollment); err != nil {
+		return nil, err
+	}
+	if err := a.store.ReplaceRecoveryCodes(ctx, username, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTOTP elimina el doble factor previa verificación de un código.
+func (a *AuthService) DisableTOTP(ctx context.Context, username, code string) error {
+	enrollment, err := a.store.GetTOTP(ctx, username)
+	if err != nil {
+		return err
+	}
+	if enrollment.Confirmed {
+		if err := a.checkSecondFactor(ctx, enrollment, code); err != nil {
+			return err
+		}
+	}
+	return a.store.DeleteTOTP(ctx, username)
 }
 
-func (a *AuthService) Login(password string) (string, error) {
-	if password != a.adminPassword {
-		return "", errors.New("contraseña inválida")
+func (a *AuthService) checkSecondFactor(ctx context.Context, enrollment models.TOTPEnrollment, code string) error {
+	code = strings.TrimSpace(code)
+	if step, ok := matchTOTP(enrollment.Secret, code, time.Now()); ok {
+		// Un mismo código no puede reutilizarse dentro de su ventana.
+		fresh, err := a.store.MarkTOTPStepUsed(ctx, enrollment.Username, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return ErrInvalidTOTP
+		}
+		return nil
+	}
+
+	used, err := a.store.ConsumeRecoveryCode(ctx, enrollment.Username, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTOTP
+	}
+	return nil
+}
+
+func (a *AuthService) sign(session Session) (string, error) {
+	payload, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+	body := base64.RawURLEncoding.EncodeToString(payload)
+	return body + "." + base64.RawURLEncoding.EncodeToString(a.mac(body)), nil
+}
+
+func (a *AuthService) parse(token string) (Session, error) {
+	body, sig, ok := strings.Cut(token, ".")
+	if !ok || body == "" {
+		return Session{}, ErrInvalidToken
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, a.mac(body)) {
+		return Session{}, ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return Session{}, ErrInvalidToken
+	}
+	var session Session
+	if err := json.Unmarshal(payload, &session); err != nil || session.ID == "" {
+		return Session{}, ErrInvalidToken
 	}
-	// token estable pero difícil de adivinar
-	hash := sha256.Sum256([]byte(fmt.Sprintf("%s-%d", password, time.Now().UnixNano())))
-	a.token = hex.EncodeToString(hash[:])
-	return a.token, nil
+	return session, nil
 }
 
-func (a *AuthService) Validate(token string) bool {
-	return token != "" && token == a.token
+func (a *AuthService) mac(body string) []byte {
+	h := hmac.New(sha256.New, a.secret)
+	h.Write([]byte(body))
+	return h.Sum(nil)
 }
@@ -0,0 +1,205 @@
+package raffle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"apiSorteos/internal/repository"
+)
+
+func newTestAuth(t *testing.T) (*AuthService, *repository.InMemoryRepository) {
+	t.Helper()
+	repo := repository.NewInMemoryRepository()
+	auth := NewAuthService(AuthConfig{
+		Users:  map[string]string{"admin": "navidad2024"},
+		Secret: []byte("secreto-de-prueba"),
+		TTL:    time.Hour,
+		Issuer: "Sorteo de prueba",
+	}, repo)
+	return auth, repo
+}
+
+// codeAt calcula el código de la app de autenticación delta pasos después del actual.
+func codeAt(t *testing.T, secret string, delta int64) string {
+	t.Helper()
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return totpCode(key, time.Now().Unix()/totpPeriod+delta)
+}
+
+// enrollTOTP activa el doble factor con el código del paso actual y devuelve
+// ese código junto con los de recuperación.
+func enrollTOTP(t *testing.T, auth *AuthService) (secret, confirmed string, recovery []string) {
+	t.Helper()
+	ctx := context.Background()
+	setup, err := auth.BeginTOTPEnrollment(ctx, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	confirmed = codeAt(t, setup.Secret, 0)
+	recovery, err = auth.ConfirmTOTP(ctx, "admin", confirmed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return setup.Secret, confirmed, recovery
+}
+
+func TestLoginWithTOTP(t *testing.T) {
+	auth, _ := newTestAuth(t)
+	ctx := context.Background()
+	secret, _, _ := enrollTOTP(t, auth)
+
+	if _, err := auth.Login(ctx, "admin", "otra", ""); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("contraseña incorrecta = %v, se esperaba %v", err, ErrInvalidPassword)
+	}
+	if _, err := auth.Login(ctx, "admin", "navidad2024", ""); !errors.Is(err, ErrTOTPRequired) {
+		t.Errorf("sin código = %v, se esperaba %v", err, ErrTOTPRequired)
+	}
+	if _, err := auth.Login(ctx, "admin", "navidad2024", "000000"); !errors.Is(err, ErrInvalidTOTP) {
+		t.Errorf("código incorrecto = %v, se esperaba %v", err, ErrInvalidTOTP)
+	}
+	if _, err := auth.Login(ctx, "admin", "navidad2024", codeAt(t, secret, 3)); !errors.Is(err, ErrInvalidTOTP) {
+		t.Errorf("código fuera de la ventana = %v, se esperaba %v", err, ErrInvalidTOTP)
+	}
+
+	// El código del paso siguiente entra en la ventana de desfase.
+	token, err := auth.Login(ctx, "admin", "navidad2024", codeAt(t, secret, 1))
+	if err != nil {
+		t.Fatalf("código vigente = %v", err)
+	}
+	if _, err := auth.Validate(ctx, token); err != nil {
+		t.Errorf("el token emitido no es válido: %v", err)
+	}
+}
+
+func TestLoginRejectsReplayedTOTPStep(t *testing.T) {
+	auth, _ := newTestAuth(t)
+	ctx := context.Background()
+	secret, confirmed, _ := enrollTOTP(t, auth)
+
+	// El código usado para confirmar ya consumió su paso.
+	if _, err := auth.Login(ctx, "admin", "navidad2024", confirmed); !errors.Is(err, ErrInvalidTOTP) {
+		t.Errorf("código de la confirmación = %v, se esperaba %v", err, ErrInvalidTOTP)
+	}
+
+	code := codeAt(t, secret, 1)
+	if _, err := auth.Login(ctx, "admin", "navidad2024", code); err != nil {
+		t.Fatalf("primer uso = %v", err)
+	}
+	if _, err := auth.Login(ctx, "admin", "navidad2024", code); !errors.Is(err, ErrInvalidTOTP) {
+		t.Errorf("repetición = %v, se esperaba %v", err, ErrInvalidTOTP)
+	}
+}
+
+func TestRecoveryCodesAreSingleUse(t *testing.T) {
+	auth, _ := newTestAuth(t)
+	ctx := context.Background()
+	_, _, recovery := enrollTOTP(t, auth)
+	if len(recovery) != recoveryCodeCount {
+		t.Fatalf("se generaron %d códigos, se esperaban %d", len(recovery), recoveryCodeCount)
+	}
+
+	// Se aceptan sin guion y en mayúsculas, como suelen copiarse.
+	typed := strings.ToUpper(strings.ReplaceAll(recovery[0], "-", ""))
+	if _, err := auth.Login(ctx, "admin", "navidad2024", typed); err != nil {
+		t.Fatalf("código de recuperación = %v", err)
+	}
+	if _, err := auth.Login(ctx, "admin", "navidad2024", recovery[0]); !errors.Is(err, ErrInvalidTOTP) {
+		t.Errorf("código de recuperación reutilizado = %v, se esperaba %v", err, ErrInvalidTOTP)
+	}
+	if _, err := auth.Login(ctx, "admin", "navidad2024", recovery[1]); err != nil {
+		t.Errorf("otro código de recuperación = %v", err)
+	}
+}
+
+func TestLogoutRevokesOnlyThatSession(t *testing.T) {
+	auth, _ := newTestAuth(t)
+	ctx := context.Background()
+	first, err := auth.Login(ctx, "admin", "navidad2024", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := auth.Login(ctx, "admin", "navidad2024", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := auth.Logout(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.Validate(ctx, first); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("token cerrado = %v, se esperaba %v", err, ErrTokenRevoked)
+	}
+	if err := auth.Logout(ctx, first); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("segundo logout = %v, se esperaba %v", err, ErrTokenRevoked)
+	}
+	if _, err := auth.Validate(ctx, second); err != nil {
+		t.Errorf("la otra sesión quedó revocada: %v", err)
+	}
+}
+
+func TestRevokeAllCutsOffEarlierSessions(t *testing.T) {
+	auth, _ := newTestAuth(t)
+	ctx := context.Background()
+	before, err := auth.Login(ctx, "admin", "navidad2024", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := auth.RevokeAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// El corte incluye el instante en que se pidió; la pausa evita que el
+	// login siguiente caiga en el mismo tic del reloj.
+	time.Sleep(time.Millisecond)
+	after, err := auth.Login(ctx, "admin", "navidad2024", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := auth.Validate(ctx, before); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("sesión previa al corte = %v, se esperaba %v", err, ErrTokenRevoked)
+	}
+	if _, err := auth.Validate(ctx, after); err != nil {
+		t.Errorf("sesión posterior al corte = %v", err)
+	}
+}
+
+func TestExpiredRevocationsArePruned(t *testing.T) {
+	auth, repo := newTestAuth(t)
+	ctx := context.Background()
+	issued := time.Now().UTC().Add(-2 * time.Hour)
+	expired, err := auth.sign(Session{ID: "vencida", Subject: "admin", IssuedAt: issued, ExpiresAt: issued.Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Un token vencido no puede cerrarse ni dejar entradas nuevas.
+	if err := auth.Logout(ctx, expired); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("logout de un token vencido = %v, se esperaba %v", err, ErrTokenExpired)
+	}
+
+	// Una revocación cuya sesión ya venció se descarta con la siguiente, y el
+	// token sigue rechazado por su propia expiración.
+	if err := repo.RevokeSession(ctx, "vencida", issued.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	current, err := auth.Login(ctx, "admin", "navidad2024", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := auth.Logout(ctx, current); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, err := repo.IsSessionRevoked(ctx, "vencida", time.Now().UTC()); err != nil || revoked {
+		t.Errorf("IsSessionRevoked(vencida) = %v, %v; la entrada vencida no se depuró", revoked, err)
+	}
+	if _, err := auth.Validate(ctx, expired); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("token vencido = %v, se esperaba %v", err, ErrTokenExpired)
+	}
+}
@@ -0,0 +1,98 @@
+package raffle
+
+import (
+	"context"
+	"errors"
+
+	"apiSorteos/internal/apperr"
+	"apiSorteos/internal/models"
+	"apiSorteos/internal/repository"
+)
+
+// Acciones que el control remoto puede pedir a las pantallas.
+const (
+	PresenterIdle         = "idle"
+	PresenterCountdown    = "countdown"
+	PresenterReveal       = "reveal"
+	PresenterCelebrate    = "celebrate"
+	PresenterWinnersBoard = "winners-board"
+)
+
+const (
+	defaultCountdown = 5
+	maxCountdown     = 60
+	winnersBoardSize = 20
+)
+
+var (
+	ErrUnknownPresenterAction = apperr.New("UNKNOWN_PRESENTER_ACTION", "acción de presentación desconocida")
+	ErrInvalidCountdown       = apperr.New("INVALID_COUNTDOWN", "la cuenta regresiva debe durar entre 1 y 60 segundos")
+	ErrWinnerNotFound         = apperr.New("WINNER_NOT_FOUND", "no hay un ganador para mostrar")
+)
+
+type PresenterCommand struct {
+	Action   string `json:"action"`
+	Seconds  int    `json:"seconds,omitempty"`
+	WinnerID int    `json:"winnerId,omitempty"`
+}
+
+// PresenterEvent es lo que reciben las pantallas: la acción junto con los
+// datos necesarios para mostrarla sin consultar la API.
+type PresenterEvent struct {
+	Action  string                `json:"action"`
+	Seconds int                   `json:"seconds,omitempty"`
+	Winner  *models.WinnerRecord  `json:"winner,omitempty"`
+	Winners []models.WinnerRecord `json:"winners,omitempty"`
+}
+
+// Present difunde un comando del control remoto como evento "presenter".
+func (s *Service) Present(ctx context.Context, cmd PresenterCommand) (PresenterEvent, error) {
+	evt := PresenterEvent{Action: cmd.Action}
+	switch cmd.Action {
+	case PresenterIdle, PresenterCelebrate:
+	case PresenterCountdown:
+		evt.Seconds = cmd.Seconds
+		if evt.Seconds == 0 {
+			evt.Seconds = defaultCountdown
+		}
+		if evt.Seconds < 1 || evt.Seconds > maxCountdown {
+			return PresenterEvent{}, ErrInvalidCountdown
+		}
+	case PresenterReveal:
+		winner, err := s.revealWinner(ctx, cmd.WinnerID)
+		if err != nil {
+			return PresenterEvent{}, err
+		}
+		evt.Winner = &winner
+	case PresenterWinnersBoard:
+		winners, err := s.repo.ListRecentWinners(ctx, winnersBoardSize)
+		if err != nil {
+			return PresenterEvent{}, err
+		}
+		evt.Winners = winners
+	default:
+		return PresenterEvent{}, ErrUnknownPresenterAction
+	}
+
+	s.broadcast(ctx, Event{Type: "presenter", Data: evt})
+	return evt, nil
+}
+
+// revealWinner busca el premio pedido o, sin id, el último entregado.
+func (s *Service) revealWinner(ctx context.Context, id int) (models.WinnerRecord, error) {
+	if id == 0 {
+		winners, err := s.repo.ListRecentWinners(ctx, 1)
+		if err != nil {
+			return models.WinnerRecord{}, err
+		}
+		if len(winners) == 0 {
+			return models.WinnerRecord{}, ErrWinnerNotFound
+		}
+		return winners[0], nil
+	}
+	winner, err := s.repo.GetWinner(ctx, id)
+	if errors.Is(err, repository.ErrAwardNotFound) {
+		return models.WinnerRecord{}, ErrWinnerNotFound
+	}
+	return winner, err
+}
@@ -0,0 +1,64 @@
+package raffle
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"apiSorteos/internal/models"
+	"apiSorteos/internal/repository"
+)
+
+// forgetfulRepo no lista ganadores recientes, para comprobar que revelar un
+// ganador por id no depende de esa lista.
+type forgetfulRepo struct {
+	*repository.InMemoryRepository
+}
+
+func (forgetfulRepo) ListRecentWinners(context.Context, int) ([]models.WinnerRecord, error) {
+	return nil, nil
+}
+
+func TestPresentRevealLooksUpWinnerByID(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryRepository()
+	signer, err := NewSigner("Sorteo de prueba", bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewService(forgetfulRepo{repo}, signer, &manualBus{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := s.RegisterSpin(ctx, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.RegisterSpin(ctx, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evt, err := s.Present(ctx, PresenterCommand{Action: PresenterReveal, WinnerID: first.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evt.Winner == nil || evt.Winner.ID != first.ID || evt.Winner.Signature != first.Signature {
+		t.Errorf("se reveló %+v, se esperaba el ganador %d", evt.Winner, first.ID)
+	}
+
+	if _, err := s.VoidAward(ctx, second.ID, "error de carga", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []int{second.ID, 99} {
+		if _, err := s.Present(ctx, PresenterCommand{Action: PresenterReveal, WinnerID: id}); !errors.Is(err, ErrWinnerNotFound) {
+			t.Errorf("revelar %d = %v, se esperaba %v", id, err, ErrWinnerNotFound)
+		}
+	}
+	// Sin id se revela el último entregado, que aquí la lista no conoce.
+	if _, err := s.Present(ctx, PresenterCommand{Action: PresenterReveal}); !errors.Is(err, ErrWinnerNotFound) {
+		t.Errorf("revelar sin id = %v, se esperaba %v", err, ErrWinnerNotFound)
+	}
+}
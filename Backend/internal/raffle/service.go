@@ -2,58 +2,279 @@ package raffle
 
 import (
 	"context"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"apiSorteos/internal/apperr"
 	"apiSorteos/internal/models"
 	"apiSorteos/internal/repository"
 )
 
+// historySize es cuántos eventos se conservan para reenviarlos a clientes
+// que se reconectan con Last-Event-ID.
+const (
+	historySize  = 256
+	clientBuffer = 32
+	// maxDroppedEvents es cuántos eventos seguidos puede perder un cliente
+	// lento antes de desconectarlo; hasta entonces se le reenvía una foto del
+	// estado, y al recibirla vuelve a empezar la cuenta.
+	maxDroppedEvents = 32
+	// HeartbeatInterval es cada cuánto se envía un comentario SSE para que
+	// proxies y navegadores no den la conexión por muerta.
+	HeartbeatInterval = 15 * time.Second
+
+	DefaultStateSample = 50
+	defaultPageSize    = 50
+	maxPageSize        = 500
+)
+
 type Service struct {
 	repo      repository.Repository
+	signer    *Signer
+	bus       EventBus
 	clientsMu sync.Mutex
 	clients   map[*Client]struct{}
+	lastID    uint64
+	history   []Event
+	paused    atomic.Bool
+	dropped   atomic.Uint64
+	// version crece con cada cambio de estado; entre réplicas se sincroniza
+	// con la mayor versión recibida por el bus.
+	version     atomic.Uint64
+	stateSample int
+	// spinMu ordena el alta de giros frente al cierre para que Shutdown no
+	// deje de esperar un giro que empezó justo antes.
+	spinMu  sync.Mutex
+	spins   sync.WaitGroup
+	closing atomic.Bool
+	// published se llama solo en la réplica que originó el evento, para que
+	// las integraciones externas lo reciban una única vez.
+	published []func(Event)
 }
 
+var (
+	ErrPaused                   = apperr.New("RAFFLE_PAUSED", "el sorteo está en pausa")
+	ErrShuttingDown             = apperr.New("SHUTTING_DOWN", "el servidor se está reiniciando")
+	ErrInvalidParticipantStatus = apperr.New("INVALID_PARTICIPANT_STATUS", "estado de participante desconocido")
+	ErrVoidReasonRequired       = apperr.New("VOID_REASON_REQUIRED", "indica el motivo de la anulación")
+)
+
 type Client struct {
-	ch   chan Event
-	done chan struct{}
+	ch      chan Event
+	done    chan struct{}
+	resync  chan struct{}
+	dropped atomic.Uint64
+	// lagging cuenta los eventos perdidos desde la última foto entregada.
+	lagging     atomic.Uint64
+	connectedAt time.Time
 }
 
 func (c *Client) Chan() <-chan Event {
 	return c.ch
 }
 
+// Resync avisa que el cliente perdió eventos y debe recibir una foto nueva del estado.
+func (c *Client) Resync() <-chan struct{} {
+	return c.resync
+}
+
+func (c *Client) Dropped() uint64 {
+	return c.dropped.Load()
+}
+
+type ClientStats struct {
+	ConnectedAt   time.Time `json:"connectedAt"`
+	DroppedEvents uint64    `json:"droppedEvents"`
+	Pending       int       `json:"pending"`
+}
+
+// Event.ID es creciente para los eventos difundidos a todos los clientes;
+// las fotos de estado que recibe un solo cliente no tienen id propio.
+// Version solo se informa en los eventos que cambian el estado del sorteo.
 type Event struct {
-	Type string      `json:"type"`
-	Data interface{} `json:"data"`
+	ID      uint64      `json:"id,omitempty"`
+	Type    string      `json:"type"`
+	Version uint64      `json:"version,omitempty"`
+	Data    interface{} `json:"data"`
 }
 
-func NewService(repo repository.Repository) *Service {
-	return &Service{
+// ErrorData es el contenido de los eventos "error". Message va en el idioma
+// por defecto; cada conexión lo traduce a partir de Code.
+type ErrorData struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func errorEvent(err error) Event {
+	return Event{Type: "error", Data: ErrorData{Code: apperr.Code(err), Message: err.Error()}}
+}
+
+// StatePatch describe el cambio de estado tras un giro. El cliente solo debe
+// aplicarlo si su versión coincide con BaseVersion; si no, pide el estado completo.
+type StatePatch struct {
+	Version         uint64                `json:"version"`
+	BaseVersion     uint64                `json:"baseVersion"`
+	RemovedPeople   []int                 `json:"removedPeople,omitempty"`
+	RemovedPrizes   []int                 `json:"removedPrizes,omitempty"`
+	AddedWinners    []models.WinnerRecord `json:"addedWinners,omitempty"`
+	RemainingPeople int                   `json:"remainingPeople"`
+	RemainingPrizes int                   `json:"remainingPrizes"`
+}
+
+// EventBus reparte los eventos entre todas las réplicas del servidor. Publish
+// asigna y devuelve el id del evento; Subscribe recibe los eventos de todas
+// las réplicas, incluida la propia.
+type EventBus interface {
+	Publish(ctx context.Context, evt Event) (uint64, error)
+	Subscribe(deliver func(Event)) error
+	Close() error
+}
+
+func NewService(repo repository.Repository, signer *Signer, bus EventBus) (*Service, error) {
+	s := &Service{
 		repo:    repo,
+		signer:  signer,
+		bus:     bus,
 		clients: map[*Client]struct{}{},
+		history: make([]Event, 0, historySize),
+
+		stateSample: DefaultStateSample,
+	}
+	if err := bus.Subscribe(s.deliver); err != nil {
+		return nil, err
 	}
+	return s, nil
 }
 
-func (s *Service) RegisterClient() *Client {
-	client := &Client{ch: make(chan Event, 4), done: make(chan struct{})}
+// RegisterClient da de alta un cliente. Si lastEventID todavía está en el
+// historial se le reenvían los eventos posteriores; si no, recibe una foto
+// completa del estado.
+func (s *Service) RegisterClient(lastEventID uint64) *Client {
+	// La foto se arma antes de tomar clientsMu porque consulta el repositorio,
+	// y se encola antes de publicar al cliente en s.clients: desde ese momento
+	// deliver o Shutdown pueden llenar o cerrar su canal.
 	s.clientsMu.Lock()
-	s.clients[client] = struct{}{}
+	_, replayable := s.eventsSince(lastEventID)
 	s.clientsMu.Unlock()
-
-	if state, err := s.State(context.Background()); err == nil {
-		client.ch <- Event{Type: "state", Data: state}
-	} else {
-		client.ch <- Event{Type: "error", Data: err.Error()}
+	var snapshot *Event
+	if !replayable {
+		evt := s.Snapshot(context.Background())
+		snapshot = &evt
 	}
 
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+	missed, ok := s.eventsSince(lastEventID)
+	client := &Client{
+		ch:          make(chan Event, clientBuffer+len(missed)+1),
+		done:        make(chan struct{}),
+		resync:      make(chan struct{}, 1),
+		connectedAt: time.Now().UTC(),
+	}
+	switch {
+	case ok:
+		for _, evt := range missed {
+			client.ch <- evt
+		}
+	case snapshot != nil:
+		client.ch <- *snapshot
+	default:
+		// El historial rotó entre las dos lecturas: la foto se pide al handler.
+		client.resync <- struct{}{}
+	}
+	if s.closing.Load() {
+		// Durante el cierre no se aceptan clientes: se les avisa y se corta.
+		client.ch <- restartingEvent()
+		close(client.ch)
+		close(client.done)
+		return client
+	}
+	s.clients[client] = struct{}{}
 	return client
 }
 
+// Snapshot arma el evento "state" que recibe un cliente al conectarse o al resincronizarse.
+func (s *Service) Snapshot(ctx context.Context) Event {
+	state, err := s.State(ctx)
+	if err != nil {
+		return errorEvent(err)
+	}
+	return Event{Type: "state", Data: state}
+}
+
+// ResyncEvent descarta los eventos pendientes, que ya quedaron desfasados, y
+// devuelve una foto actual del estado para reemplazarlos.
+func (s *Service) ResyncEvent(ctx context.Context, c *Client) Event {
+	drainEvents(c.ch)
+	c.lagging.Store(0)
+	return s.Snapshot(ctx)
+}
+
+func drainEvents(ch chan Event) {
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (s *Service) ClientCount() int {
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+	return len(s.clients)
+}
+
+// DroppedEvents es el total de eventos descartados por clientes lentos.
+func (s *Service) DroppedEvents() uint64 {
+	return s.dropped.Load()
+}
+
+func (s *Service) ClientStats() []ClientStats {
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+
+	stats := make([]ClientStats, 0, len(s.clients))
+	for c := range s.clients {
+		stats = append(stats, ClientStats{
+			ConnectedAt:   c.connectedAt,
+			DroppedEvents: c.Dropped(),
+			Pending:       len(c.ch),
+		})
+	}
+	return stats
+}
+
+// eventsSince debe llamarse con clientsMu tomado. Devuelve false si no hay
+// id previo o si ya salió del historial y no es posible reconstruir la secuencia.
+func (s *Service) eventsSince(lastEventID uint64) ([]Event, bool) {
+	if lastEventID == 0 || lastEventID > s.lastID {
+		return nil, false
+	}
+	if len(s.history) == 0 || s.history[0].ID > lastEventID+1 {
+		return nil, false
+	}
+	idx := sort.Search(len(s.history), func(i int) bool { return s.history[i].ID > lastEventID })
+	return append([]Event(nil), s.history[idx:]...), true
+}
+
 func (s *Service) UnregisterClient(c *Client) {
 	s.clientsMu.Lock()
 	defer s.clientsMu.Unlock()
+	s.removeClient(c)
+}
+
+// removeClient debe llamarse con clientsMu tomado.
+func (s *Service) removeClient(c *Client) {
 	if _, ok := s.clients[c]; ok {
 		delete(s.clients, c)
 		close(c.ch)
@@ -61,19 +282,100 @@ func (s *Service) UnregisterClient(c *Client) {
 	}
 }
 
-func (s *Service) broadcast(evt Event) {
+// SetStateSample fija cuántas personas en espera se incluyen en el estado.
+func (s *Service) SetStateSample(n int) {
+	s.stateSample = max(n, 0)
+}
+
+// OnPublish registra fn para cada evento que publica esta réplica. Debe
+// llamarse antes de atender peticiones.
+func (s *Service) OnPublish(fn func(Event)) {
+	s.published = append(s.published, fn)
+}
+
+// broadcast publica aunque la petición que originó el evento se cancele: un
+// giro ya guardado debe llegar a las pantallas.
+func (s *Service) broadcast(ctx context.Context, evt Event) {
+	id, err := s.bus.Publish(context.WithoutCancel(ctx), evt)
+	if err != nil {
+		slog.ErrorContext(ctx, "No se pudo publicar el evento", "type", evt.Type, "error", err)
+		return
+	}
+	evt.ID = id
+	for _, fn := range s.published {
+		fn(evt)
+	}
+}
+
+// deliver recibe los eventos del bus y los reparte a los clientes conectados a esta réplica.
+func (s *Service) deliver(evt Event) {
+	// La pausa se replica entre instancias a través del propio bus.
+	switch evt.Type {
+	case "paused":
+		s.paused.Store(true)
+	case "resumed":
+		s.paused.Store(false)
+	}
+
+	for current := s.version.Load(); evt.Version > current; current = s.version.Load() {
+		if s.version.CompareAndSwap(current, evt.Version) {
+			break
+		}
+	}
+
 	s.clientsMu.Lock()
 	defer s.clientsMu.Unlock()
+
+	// Un id que no sigue al último visto significa que el bus perdió eventos:
+	// el historial ya no sirve para reenviarlos y cada cliente necesita una foto.
+	gap := s.lastID != 0 && evt.ID > s.lastID+1
+	if gap {
+		slog.Warn("Faltan eventos del bus, se reenvía el estado a los clientes", "lastId", s.lastID, "received", evt.ID)
+		s.history = s.history[:0]
+	}
+	s.lastID = max(s.lastID, evt.ID)
+	if len(s.history) == historySize {
+		s.history = append(s.history[:0], s.history[1:]...)
+	}
+	// Se inserta por id por si un evento recuperado llega tarde.
+	idx := sort.Search(len(s.history), func(i int) bool { return s.history[i].ID > evt.ID })
+	s.history = slices.Insert(s.history, idx, evt)
+
 	for c := range s.clients {
+		if gap {
+			// La foto que arma ResyncEvent ya incluye este evento.
+			select {
+			case c.resync <- struct{}{}:
+			default:
+			}
+			continue
+		}
 		select {
 		case c.ch <- evt:
 		default:
+			s.dropped.Add(1)
+			c.dropped.Add(1)
+			if lagging := c.lagging.Add(1); lagging > maxDroppedEvents {
+				slog.Warn("Cliente SSE desconectado por lento", "droppedEvents", c.Dropped(), "consecutive", lagging)
+				s.removeClient(c)
+				continue
+			}
+			select {
+			case c.resync <- struct{}{}:
+			default:
+			}
 		}
 	}
 }
 
+// State lee la versión antes que los datos: si otro cambio se cuela en medio,
+// el cliente recibe ese parche con una versión conocida y lo aplica sin pérdida.
 func (s *Service) State(ctx context.Context) (models.RaffleState, error) {
-	people, err := s.repo.ListParticipants(ctx)
+	version := s.version.Load()
+	people, remaining, err := s.repo.SearchParticipants(ctx, models.ParticipantFilter{
+		Status: models.ParticipantsWaiting,
+		Limit:  s.stateSample,
+	})
 	if err != nil {
 		return models.RaffleState{}, err
 	}
@@ -89,14 +391,62 @@ func (s *Service) State(ctx context.Context) (models.RaffleState, error) {
 	}
 
 	return models.RaffleState{
-		RemainingPeople: len(people),
+		RemainingPeople: remaining,
 		RemainingPrizes: len(prizes),
 		RecentWinners:   winners,
 		UpcomingPrizes:  prizes,
 		WaitingPeople:   people,
+		Paused:          s.paused.Load(),
+		Version:         version,
 	}, nil
 }
 
+// SearchParticipants pagina a los participantes; page empieza en 1.
+func (s *Service) SearchParticipants(ctx context.Context, status, query string, page, pageSize int) (models.ParticipantPage, error) {
+	switch status {
+	case "":
+		status = models.ParticipantsWaiting
+	case models.ParticipantsWaiting, models.ParticipantsAwarded, models.ParticipantsAll:
+	default:
+		return models.ParticipantPage{}, ErrInvalidParticipantStatus
+	}
+	page = max(page, 1)
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+
+	people, total, err := s.repo.SearchParticipants(ctx, models.ParticipantFilter{
+		Status: status,
+		Query:  strings.TrimSpace(query),
+		Offset: (page - 1) * pageSize,
+		Limit:  pageSize,
+	})
+	if err != nil {
+		return models.ParticipantPage{}, err
+	}
+	return models.ParticipantPage{Items: people, Total: total, Page: page, PageSize: pageSize}, nil
+}
+
+// nextVersion reserva la versión del próximo cambio de estado.
+func (s *Service) nextVersion() (base, next uint64) {
+	next = s.version.Add(1)
+	return next - 1, next
+}
+
+// Pause bloquea nuevos giros hasta llamar a Resume.
+func (s *Service) Pause(ctx context.Context) {
+	if s.paused.CompareAndSwap(false, true) {
+		s.broadcast(ctx, Event{Type: "paused", Data: struct{}{}})
+	}
+}
+
+func (s *Service) Resume(ctx context.Context) {
+	if s.paused.CompareAndSwap(true, false) {
+		s.broadcast(ctx, Event{Type: "resumed", Data: struct{}{}})
+	}
+}
+
 type SpinEnvelope struct {
 	StartedAt       time.Time      `json:"startedAt"`
 	SelectedPrize   models.Prize   `json:"selectedPrize"`
@@ -107,29 +457,32 @@ type SpinEnvelope struct {
 }
 
 func (s *Service) RegisterSpin(ctx context.Context, participantID, prizeID int) (models.WinnerRecord, error) {
+	if !s.beginSpin() {
+		return models.WinnerRecord{}, ErrShuttingDown
+	}
+	defer s.spins.Done()
+
+	if s.paused.Load() {
+		return models.WinnerRecord{}, ErrPaused
+	}
 	if participantID == 0 || prizeID == 0 {
 		return models.WinnerRecord{}, repository.ErrNothingToRegister
 	}
 
-	people, err := s.repo.ListParticipants(ctx)
+	person, err := s.repo.GetParticipant(ctx, participantID)
 	if err != nil {
 		return models.WinnerRecord{}, err
 	}
 
-	prizes, err := s.repo.ListPrizes(ctx)
+	// Con Limit 0 el repositorio solo cuenta, sin traer a nadie.
+	_, remaining, err := s.repo.SearchParticipants(ctx, models.ParticipantFilter{Status: models.ParticipantsWaiting})
 	if err != nil {
 		return models.WinnerRecord{}, err
 	}
 
-	var person models.Person
-	for _, p := range people {
-		if p.ID == participantID {
-			person = p
-			break
-		}
-	}
-	if person.ID == 0 {
-		return models.WinnerRecord{}, repository.ErrParticipantUsed
+	prizes, err := s.repo.ListPrizes(ctx)
+	if err != nil {
+		return models.WinnerRecord{}, err
 	}
 
 	var prize models.Prize
@@ -148,23 +501,111 @@ func (s *Service) RegisterSpin(ctx context.Context, participantID, prizeID int)
 		SelectedPrize:   prize,
 		SelectedPerson:  person,
 		Segments:        append([]models.Prize(nil), prizes...),
-		RemainingPeople: len(people),
+		RemainingPeople: remaining,
 		RemainingPrizes: len(prizes),
 	}
-	s.broadcast(Event{Type: "spin-start", Data: spin})
+	s.broadcast(ctx, Event{Type: "spin-start", Data: spin})
 
-	record, err := s.repo.SaveAward(ctx, participantID, prizeID)
+	record, err := s.repo.SaveAward(ctx, participantID, prizeID, s.signer.Sign)
 	if err != nil {
-		s.broadcast(Event{Type: "error", Data: err.Error()})
+		s.broadcast(ctx, errorEvent(err))
 		return models.WinnerRecord{}, err
 	}
 
-	s.broadcast(Event{Type: "spin-complete", Data: record})
+	s.broadcast(ctx, Event{Type: "spin-complete", Data: record})
+	base, next := s.nextVersion()
+	s.broadcast(ctx, Event{Type: "state-patch", Version: next, Data: StatePatch{
+		Version:         next,
+		BaseVersion:     base,
+		RemovedPeople:   []int{person.ID},
+		RemovedPrizes:   []int{prize.ID},
+		AddedWinners:    []models.WinnerRecord{record},
+		RemainingPeople: remaining - 1,
+		RemainingPrizes: len(prizes) - 1,
+	}})
+
+	return record, nil
+}
+
+// VoidAward anula un premio entregado por error. Se difunde "award-voided" y,
+// como la persona y el premio vuelven al sorteo, el estado completo.
+func (s *Service) VoidAward(ctx context.Context, winnerID int, reason, voidedBy string) (models.VoidedAward, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return models.VoidedAward{}, ErrVoidReasonRequired
+	}
+	voided, err := s.repo.VoidAward(ctx, winnerID, reason, voidedBy)
+	if err != nil {
+		return models.VoidedAward{}, err
+	}
+
+	s.broadcast(ctx, Event{Type: "award-voided", Data: voided})
+	s.nextVersion()
 	if state, err := s.State(ctx); err == nil {
-		s.broadcast(Event{Type: "state", Data: state})
-	} else {
-		s.broadcast(Event{Type: "error", Data: err.Error()})
+		s.broadcast(ctx, Event{Type: "state", Version: state.Version, Data: state})
 	}
+	return voided, nil
+}
 
-	return record, nil
+func (s *Service) beginSpin() bool {
+	s.spinMu.Lock()
+	defer s.spinMu.Unlock()
+	if s.closing.Load() {
+		return false
+	}
+	s.spins.Add(1)
+	return true
+}
+
+func restartingEvent() Event {
+	return Event{Type: "server-restarting", Data: struct{}{}}
+}
+
+// Shutdown deja de aceptar giros, espera a que terminen los giros en curso y
+// desconecta a los clientes de esta réplica tras avisarles con
+// "server-restarting". El aviso no pasa por el bus: las demás réplicas siguen activas.
+func (s *Service) Shutdown(ctx context.Context) error {
+	s.spinMu.Lock()
+	s.closing.Store(true)
+	s.spinMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.spins.Wait()
+		close(done)
+	}()
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	s.clientsMu.Lock()
+	for c := range s.clients {
+		select {
+		case c.ch <- restartingEvent():
+		default:
+		}
+		s.removeClient(c)
+	}
+	s.clientsMu.Unlock()
+	return err
+}
+
+func (s *Service) UpsertParticipants(ctx context.Context, people []models.Person) (int, int, error) {
+	created, updated, err := s.repo.UpsertParticipants(ctx, people)
+	if err != nil {
+		return 0, 0, err
+	}
+	// Una importación puede cambiar cualquier dato, así que se envía el estado completo.
+	s.nextVersion()
+	if state, err := s.State(ctx); err == nil {
+		s.broadcast(ctx, Event{Type: "state", Version: state.Version, Data: state})
+	}
+	return created, updated, nil
+}
+
+func (s *Service) Signer() *Signer {
+	return s.signer
 }
@@ -0,0 +1,199 @@
+package raffle
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"apiSorteos/internal/repository"
+)
+
+// manualBus entrega solo lo que la prueba indica, para simular pérdidas.
+type manualBus struct {
+	deliver func(Event)
+}
+
+func (b *manualBus) Publish(context.Context, Event) (uint64, error) { return 0, nil }
+func (b *manualBus) Subscribe(deliver func(Event)) error {
+	b.deliver = deliver
+	return nil
+}
+func (b *manualBus) Close() error { return nil }
+
+func newTestService(t *testing.T) (*Service, *manualBus) {
+	t.Helper()
+	signer, err := NewSigner("Sorteo de prueba", bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus := &manualBus{}
+	s, err := NewService(repository.NewInMemoryRepository(), signer, bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, bus
+}
+
+func TestDeliverGapForcesResync(t *testing.T) {
+	s, bus := newTestService(t)
+	bus.deliver(Event{ID: 1, Type: "paused"})
+
+	client := s.RegisterClient(1)
+	defer s.UnregisterClient(client)
+
+	bus.deliver(Event{ID: 2, Type: "resumed"})
+	if evt := <-client.Chan(); evt.ID != 2 {
+		t.Fatalf("se recibió el evento %d, se esperaba 2", evt.ID)
+	}
+	select {
+	case <-client.Resync():
+		t.Fatal("resync sin que falten eventos")
+	default:
+	}
+
+	bus.deliver(Event{ID: 5, Type: "paused"})
+	select {
+	case <-client.Resync():
+	default:
+		t.Fatal("el hueco entre 2 y 5 no pidió resync")
+	}
+	if evt := s.ResyncEvent(context.Background(), client); evt.Type != "state" {
+		t.Errorf("ResyncEvent = %q, se esperaba state", evt.Type)
+	}
+
+	// Tras el hueco el historial no puede reconstruir lo anterior.
+	if _, ok := s.eventsSince(2); ok {
+		t.Error("eventsSince(2) reenvía eventos a través del hueco")
+	}
+	if missed, ok := s.eventsSince(4); !ok || len(missed) != 1 || missed[0].ID != 5 {
+		t.Errorf("eventsSince(4) = %v, %v", missed, ok)
+	}
+}
+
+func TestDeliverResetsLaggingOnResync(t *testing.T) {
+	s, bus := newTestService(t)
+	client := s.RegisterClient(0)
+	defer s.UnregisterClient(client)
+	s.ResyncEvent(context.Background(), client)
+
+	// Cada tanda llena el búfer y pierde eventos, pero la foto intermedia
+	// reinicia la cuenta: el cliente no debe desconectarse.
+	id := uint64(0)
+	for round := 0; round < 3; round++ {
+		for i := 0; i < cap(client.ch)+maxDroppedEvents; i++ {
+			id++
+			bus.deliver(Event{ID: id, Type: "paused"})
+		}
+		s.ResyncEvent(context.Background(), client)
+	}
+	select {
+	case <-client.done:
+		t.Fatal("el cliente se desconectó aunque recibió la foto")
+	default:
+	}
+	if client.Dropped() != 3*maxDroppedEvents {
+		t.Errorf("Dropped = %d, se esperaba %d", client.Dropped(), 3*maxDroppedEvents)
+	}
+}
+
+func TestVoidAwardReturnsPersonAndPrize(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := context.Background()
+	var published []string
+	s.OnPublish(func(evt Event) { published = append(published, evt.Type) })
+
+	record, err := s.RegisterSpin(ctx, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.VoidAward(ctx, record.ID, "  ", "admin"); !errors.Is(err, ErrVoidReasonRequired) {
+		t.Errorf("sin motivo = %v, se esperaba %v", err, ErrVoidReasonRequired)
+	}
+
+	published = nil
+	voided, err := s.VoidAward(ctx, record.ID, "la persona no estaba presente", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if voided.Winner.ID != record.ID || voided.Winner.Signature != record.Signature || voided.VoidedBy != "admin" {
+		t.Errorf("anulación = %+v", voided)
+	}
+	if !slices.Equal(published, []string{"award-voided", "state"}) {
+		t.Errorf("eventos = %v, se esperaba [award-voided state]", published)
+	}
+
+	state, err := s.State(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.RemainingPeople != 5 || state.RemainingPrizes != 5 || len(state.RecentWinners) != 0 {
+		t.Errorf("estado tras anular = %d personas, %d premios, %d ganadores", state.RemainingPeople, state.RemainingPrizes, len(state.RecentWinners))
+	}
+	if _, err := s.VoidAward(ctx, record.ID, "otra vez", "admin"); !errors.Is(err, repository.ErrAwardNotFound) {
+		t.Errorf("segunda anulación = %v, se esperaba %v", err, repository.ErrAwardNotFound)
+	}
+	// La persona puede volver a ganar.
+	if _, err := s.RegisterSpin(ctx, 1, 3); err != nil {
+		t.Errorf("giro tras anular = %v", err)
+	}
+}
+
+// pending devuelve lo que el cliente tiene encolado sin esperar más eventos.
+func pending(c *Client) []Event {
+	var events []Event
+	for {
+		select {
+		case evt := <-c.ch:
+			events = append(events, evt)
+		default:
+			return events
+		}
+	}
+}
+
+func TestRegisterClientReplaysFromLastEventID(t *testing.T) {
+	s, bus := newTestService(t)
+	const published = historySize + 44
+	for id := uint64(1); id <= published; id++ {
+		bus.deliver(Event{ID: id, Type: "presenter", Data: struct{}{}})
+	}
+	oldest := uint64(published - historySize + 1)
+
+	tests := []struct {
+		name        string
+		lastEventID uint64
+		first       uint64
+		replayed    int
+	}{
+		{"id reciente", published - 10, published - 9, 10},
+		{"id anterior al más viejo del historial", oldest - 1, oldest, historySize},
+		{"id igual al último", published, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := s.RegisterClient(tt.lastEventID)
+			defer s.UnregisterClient(client)
+			events := pending(client)
+			if len(events) != tt.replayed {
+				t.Fatalf("se reenviaron %d eventos, se esperaban %d", len(events), tt.replayed)
+			}
+			for i, evt := range events {
+				if evt.Type == "state" || evt.ID != tt.first+uint64(i) {
+					t.Fatalf("evento %d = %s #%d, se esperaba #%d", i, evt.Type, evt.ID, tt.first+uint64(i))
+				}
+			}
+		})
+	}
+
+	// Sin forma de reconstruir la secuencia se envía una foto completa.
+	for _, lastEventID := range []uint64{0, oldest - 2, 1, published + 1, 1 << 62} {
+		client := s.RegisterClient(lastEventID)
+		events := pending(client)
+		s.UnregisterClient(client)
+		if len(events) != 1 || events[0].Type != "state" {
+			t.Errorf("RegisterClient(%d) = %v, se esperaba una foto", lastEventID, events)
+		}
+	}
+}
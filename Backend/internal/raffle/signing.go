@@ -0,0 +1,85 @@
+package raffle
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"apiSorteos/internal/apperr"
+	"apiSorteos/internal/models"
+)
+
+var ErrInvalidSigningKey = apperr.New("INVALID_SIGNING_KEY", "la clave de firma debe ser una semilla Ed25519 de 32 bytes")
+
+// Signer firma cada resultado para que pueda verificarse fuera del servidor
+// con la clave pública publicada.
+type Signer struct {
+	raffle string
+	key    ed25519.PrivateKey
+}
+
+// SignedResult es el JSON canónico que se firma: los campos se serializan
+// siempre en este orden, sin espacios y con la fecha en RFC 3339 UTC.
+type SignedResult struct {
+	Raffle    string        `json:"raffle"`
+	Person    models.Person `json:"person"`
+	Prize     models.Prize  `json:"prize"`
+	AwardedAt string        `json:"awardedAt"`
+	Seed      string        `json:"seed"`
+}
+
+func NewSigner(raffle string, seed []byte) (*Signer, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, ErrInvalidSigningKey
+	}
+	return &Signer{raffle: raffle, key: ed25519.NewKeyFromSeed(seed)}, nil
+}
+
+func (s *Signer) Raffle() string {
+	return s.raffle
+}
+
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// Sign completa Seed y Signature del registro.
+func (s *Signer) Sign(record models.WinnerRecord) (models.WinnerRecord, error) {
+	seed := make([]byte, 16)
+	if _, err := rand.Read(seed); err != nil {
+		return models.WinnerRecord{}, err
+	}
+	record.Seed = hex.EncodeToString(seed)
+
+	msg, err := s.CanonicalJSON(record)
+	if err != nil {
+		return models.WinnerRecord{}, err
+	}
+	record.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, msg))
+	return record, nil
+}
+
+func (s *Signer) Verify(record models.WinnerRecord) bool {
+	sig, err := base64.StdEncoding.DecodeString(record.Signature)
+	if err != nil {
+		return false
+	}
+	msg, err := s.CanonicalJSON(record)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(s.PublicKey(), msg, sig)
+}
+
+func (s *Signer) CanonicalJSON(record models.WinnerRecord) ([]byte, error) {
+	return json.Marshal(SignedResult{
+		Raffle:    s.raffle,
+		Person:    record.Person,
+		Prize:     record.Prize,
+		AwardedAt: record.AwardedAt.UTC().Format(time.RFC3339Nano),
+		Seed:      record.Seed,
+	})
+}
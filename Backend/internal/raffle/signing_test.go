@@ -0,0 +1,94 @@
+package raffle
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"apiSorteos/internal/models"
+)
+
+func TestSignerRoundTripAndTamper(t *testing.T) {
+	signer, err := NewSigner("Sorteo de prueba", bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := signer.Sign(models.WinnerRecord{
+		ID:        7,
+		Person:    models.Person{ID: 1, Name: "Elena Navideña", Email: "elena@example.com"},
+		Prize:     models.Prize{ID: 2, Name: "Café Invernal", Description: "Kit de café con especias"},
+		AwardedAt: time.Date(2024, 12, 24, 21, 30, 0, 123456000, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed.Seed == "" || signed.Signature == "" {
+		t.Fatalf("registro sin semilla o firma: %+v", signed)
+	}
+	if !signer.Verify(signed) {
+		t.Fatal("la firma recién emitida no verifica")
+	}
+
+	// Lo que recibe un cliente por la API también verifica con la clave pública.
+	raw, err := json.Marshal(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received models.WinnerRecord
+	if err := json.Unmarshal(raw, &received); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := signer.CanonicalJSON(received)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, _ := base64.StdEncoding.DecodeString(received.Signature)
+	if !ed25519.Verify(signer.PublicKey(), msg, sig) {
+		t.Error("el registro serializado no verifica con la clave pública")
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(*models.WinnerRecord)
+	}{
+		{"persona", func(r *models.WinnerRecord) { r.Person.ID = 2 }},
+		{"nombre", func(r *models.WinnerRecord) { r.Person.Name = "Carlos Duende" }},
+		{"email", func(r *models.WinnerRecord) { r.Person.Email = "carlos@example.com" }},
+		{"premio", func(r *models.WinnerRecord) { r.Prize.ID = 3 }},
+		{"descripción del premio", func(r *models.WinnerRecord) { r.Prize.Description = "Otro kit" }},
+		{"fecha", func(r *models.WinnerRecord) { r.AwardedAt = r.AwardedAt.Add(time.Microsecond) }},
+		{"semilla", func(r *models.WinnerRecord) { r.Seed = strings.Repeat("0", len(r.Seed)-1) + "1" }},
+		{"firma", func(r *models.WinnerRecord) { r.Signature = "no-es-base64" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := signed
+			tt.tamper(&record)
+			if record == signed {
+				t.Fatal("la alteración no cambió el registro")
+			}
+			if signer.Verify(record) {
+				t.Error("un registro alterado pasó la verificación")
+			}
+		})
+	}
+
+	other, err := NewSigner("Otro sorteo", bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Verify(signed) {
+		t.Error("la firma verifica para otro sorteo")
+	}
+}
+
+func TestNewSignerRejectsShortSeed(t *testing.T) {
+	if _, err := NewSigner("Sorteo de prueba", make([]byte, 16)); !errors.Is(err, ErrInvalidSigningKey) {
+		t.Errorf("NewSigner = %v, se esperaba %v", err, ErrInvalidSigningKey)
+	}
+}
@@ -0,0 +1,67 @@
+package raffle
+
+import (
+	"testing"
+	"time"
+)
+
+// Vectores SHA-1 del apéndice B de RFC 6238. El RFC usa 8 dígitos; con 6
+// el código son los últimos 6.
+func TestTOTPCodeRFC6238Vectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		if got := totpCode(key, tt.unix/totpPeriod); got != tt.code {
+			t.Errorf("totpCode(T=%d) = %s, se esperaba %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestMatchTOTPWindow(t *testing.T) {
+	key := []byte("12345678901234567890")
+	secret := totpEncoding.EncodeToString(key)
+	now := time.Unix(1111111111, 0)
+	current := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name  string
+		step  int64
+		match bool
+	}{
+		{"paso actual", current, true},
+		{"un paso atrás", current - 1, true},
+		{"un paso adelante", current + 1, true},
+		{"dos pasos atrás", current - 2, false},
+		{"dos pasos adelante", current + 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := matchTOTP(secret, totpCode(key, tt.step), now)
+			if ok != tt.match {
+				t.Fatalf("matchTOTP = %v, se esperaba %v", ok, tt.match)
+			}
+			if ok && step != tt.step {
+				t.Errorf("paso = %d, se esperaba %d", step, tt.step)
+			}
+		})
+	}
+
+	for _, code := range []string{"", "12345", "1234567", "abcdef"} {
+		if _, ok := matchTOTP(secret, code, now); ok {
+			t.Errorf("matchTOTP(%q) aceptó un código mal formado", code)
+		}
+	}
+	if _, ok := matchTOTP("no-es-base32!", totpCode(key, current), now); ok {
+		t.Error("matchTOTP aceptó un secreto inválido")
+	}
+}
@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// idleSweep es cada cuánto se descartan los buckets que ya se rellenaron,
+// para que la memoria no crezca con cada IP que pasó por el servidor.
+const idleSweep = time.Minute
+
+// Rule es un token bucket: Rate fichas por segundo con capacidad Burst.
+// Rate 0 desactiva el límite.
+type Rule struct {
+	Rate  float64
+	Burst int
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter aplica la misma regla de forma independiente a cada clave.
+type Limiter struct {
+	rule      Rule
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func New(rule Rule) *Limiter {
+	if rule.Burst < 1 {
+		rule.Burst = 1
+	}
+	return &Limiter{rule: rule, buckets: map[string]*bucket{}, now: time.Now}
+}
+
+// Allow consume una ficha de key. Si no quedan, devuelve cuánto falta para
+// la siguiente.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil || l.rule.Rate <= 0 {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.rule.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(l.rule.Burst), b.tokens+now.Sub(b.last).Seconds()*l.rule.Rate)
+	b.last = now
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rule.Rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep descarta los buckets que, por el tiempo transcurrido, ya estarían llenos.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleSweep {
+		return
+	}
+	l.lastSweep = now
+	refill := time.Duration(float64(l.rule.Burst) / l.rule.Rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Counter limita cuántas conexiones simultáneas mantiene cada clave.
+// Max 0 desactiva el límite.
+type Counter struct {
+	max    int
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func NewCounter(max int) *Counter {
+	return &Counter{max: max, counts: map[string]int{}}
+}
+
+// Acquire reserva una conexión para key; quien obtiene true debe llamar a Release.
+func (c *Counter) Acquire(key string) bool {
+	if c.max <= 0 {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts[key] >= c.max {
+		return false
+	}
+	c.counts[key]++
+	return true
+}
+
+func (c *Counter) Release(key string) {
+	if c.max <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts[key] <= 1 {
+		delete(c.counts, key)
+		return
+	}
+	c.counts[key]--
+}
@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// clock es un reloj manual para no depender de esperas reales.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time          { return c.now }
+func (c *clock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestLimiter(rule Rule) (*Limiter, *clock) {
+	c := &clock{now: time.Date(2024, 12, 24, 20, 0, 0, 0, time.UTC)}
+	l := New(rule)
+	l.now = c.Now
+	return l, c
+}
+
+func TestLimiterBurstThenRefill(t *testing.T) {
+	l, c := newTestLimiter(Rule{Rate: 2, Burst: 3})
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("10.0.0.1"); !ok {
+			t.Fatalf("la petición %d de la ráfaga fue rechazada", i+1)
+		}
+	}
+	ok, wait := l.Allow("10.0.0.1")
+	if ok {
+		t.Fatal("se superó la ráfaga sin rechazo")
+	}
+	if wait != 500*time.Millisecond {
+		t.Errorf("espera = %v, se esperaba 500ms", wait)
+	}
+
+	c.Advance(250 * time.Millisecond)
+	ok, wait = l.Allow("10.0.0.1")
+	if ok || wait != 250*time.Millisecond {
+		t.Errorf("a mitad de la recarga = %v, %v; se esperaba false, 250ms", ok, wait)
+	}
+
+	c.Advance(250 * time.Millisecond)
+	if ok, _ := l.Allow("10.0.0.1"); !ok {
+		t.Error("no se recargó la ficha tras 500ms")
+	}
+}
+
+func TestLimiterNeverExceedsBurst(t *testing.T) {
+	l, c := newTestLimiter(Rule{Rate: 10, Burst: 2})
+	c.Advance(time.Hour)
+
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if ok, _ := l.Allow("10.0.0.1"); ok {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Errorf("se permitieron %d peticiones, se esperaban 2", allowed)
+	}
+}
+
+func TestLimiterKeysAreIndependent(t *testing.T) {
+	l, _ := newTestLimiter(Rule{Rate: 1, Burst: 1})
+
+	if ok, _ := l.Allow("10.0.0.1"); !ok {
+		t.Fatal("primera petición rechazada")
+	}
+	if ok, _ := l.Allow("10.0.0.1"); ok {
+		t.Error("la segunda petición de la misma IP debía rechazarse")
+	}
+	if ok, _ := l.Allow("10.0.0.2"); !ok {
+		t.Error("otra IP comparte el bucket")
+	}
+}
+
+func TestLimiterDisabled(t *testing.T) {
+	var nilLimiter *Limiter
+	if ok, _ := nilLimiter.Allow("10.0.0.1"); !ok {
+		t.Error("un limitador nil debe permitir todo")
+	}
+	l, _ := newTestLimiter(Rule{Rate: 0, Burst: 1})
+	for i := 0; i < 100; i++ {
+		if ok, _ := l.Allow("10.0.0.1"); !ok {
+			t.Fatal("Rate 0 debe desactivar el límite")
+		}
+	}
+}
+
+func TestLimiterSweepsIdleBuckets(t *testing.T) {
+	l, c := newTestLimiter(Rule{Rate: 1, Burst: 5})
+	l.Allow("10.0.0.1")
+	l.Allow("10.0.0.2")
+
+	c.Advance(idleSweep + time.Second)
+	l.Allow("10.0.0.3")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.buckets) != 1 {
+		t.Errorf("quedan %d buckets, se esperaba solo el de 10.0.0.3", len(l.buckets))
+	}
+}
+
+func TestCounter(t *testing.T) {
+	c := NewCounter(2)
+	if !c.Acquire("10.0.0.1") || !c.Acquire("10.0.0.1") {
+		t.Fatal("no se pudieron abrir dos conexiones")
+	}
+	if c.Acquire("10.0.0.1") {
+		t.Error("se abrió una tercera conexión")
+	}
+	if !c.Acquire("10.0.0.2") {
+		t.Error("otra IP comparte el contador")
+	}
+	c.Release("10.0.0.1")
+	if !c.Acquire("10.0.0.1") {
+		t.Error("Release no liberó el lugar")
+	}
+
+	unlimited := NewCounter(0)
+	for i := 0; i < 100; i++ {
+		if !unlimited.Acquire("10.0.0.1") {
+			t.Fatal("Max 0 debe desactivar el límite")
+		}
+	}
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,15 +15,43 @@ type InMemoryRepository struct {
 	people       []models.Person
 	prizes       []models.Prize
 	winners      []models.WinnerRecord
+	voided       []models.VoidedAward
 	nextWinnerID int
+	nextPersonID int
+
+	revokedSessions map[string]time.Time
+	revokedBefore   time.Time
+
+	totp          map[string]models.TOTPEnrollment
+	recoveryCodes map[string]map[string]bool
+
+	apiKeys      []models.APIKey
+	nextAPIKeyID int
+
+	audit []models.AuditEntry
+
+	webhooks          []models.Webhook
+	nextWebhookID     int
+	webhookDeliveries []models.WebhookDelivery
 }
 
 func NewInMemoryRepository() *InMemoryRepository {
+	people := samplePeople()
 	return &InMemoryRepository{
-		people:       samplePeople(),
+		people:       people,
 		prizes:       samplePrizes(),
 		winners:      []models.WinnerRecord{},
 		nextWinnerID: 1,
+		nextPersonID: len(people) + 1,
+
+		revokedSessions: map[string]time.Time{},
+
+		totp:          map[string]models.TOTPEnrollment{},
+		recoveryCodes: map[string]map[string]bool{},
+
+		nextAPIKeyID: 1,
+
+		nextWebhookID: 1,
 	}
 }
 
@@ -45,6 +75,10 @@ func samplePrizes() []models.Prize {
 	}
 }
 
+func (r *InMemoryRepository) Ping(_ context.Context) error {
+	return nil
+}
+
 func (r *InMemoryRepository) ListParticipants(_ context.Context) ([]models.Person, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -54,6 +88,46 @@ func (r *InMemoryRepository) ListParticipants(_ context.Context) ([]models.Perso
 	return people, nil
 }
 
+func (r *InMemoryRepository) SearchParticipants(_ context.Context, filter models.ParticipantFilter) ([]models.Person, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var candidates []models.Person
+	if filter.Status != models.ParticipantsAwarded {
+		candidates = append(candidates, r.people...)
+	}
+	if filter.Status != models.ParticipantsWaiting {
+		for _, w := range r.winners {
+			candidates = append(candidates, w.Person)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID < candidates[j].ID })
+
+	query := strings.ToLower(filter.Query)
+	matches := []models.Person{}
+	for _, p := range candidates {
+		if query == "" || strings.Contains(strings.ToLower(p.Name), query) || strings.Contains(strings.ToLower(p.Email), query) {
+			matches = append(matches, p)
+		}
+	}
+
+	start := min(filter.Offset, len(matches))
+	end := min(start+filter.Limit, len(matches))
+	return append([]models.Person{}, matches[start:end]...), len(matches), nil
+}
+
+func (r *InMemoryRepository) GetParticipant(_ context.Context, id int) (models.Person, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range r.people {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return models.Person{}, ErrParticipantUsed
+}
+
 func (r *InMemoryRepository) ListPrizes(_ context.Context) ([]models.Prize, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -79,7 +153,19 @@ func (r *InMemoryRepository) ListRecentWinners(_ context.Context, limit int) ([]
 	return winners, nil
 }
 
-func (r *InMemoryRepository) SaveAward(_ context.Context, participantID, prizeID int) (models.WinnerRecord, error) {
+func (r *InMemoryRepository) GetWinner(_ context.Context, id int) (models.WinnerRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, w := range r.winners {
+		if w.ID == id {
+			return w, nil
+		}
+	}
+	return models.WinnerRecord{}, ErrAwardNotFound
+}
+
+func (r *InMemoryRepository) SaveAward(_ context.Context, participantID, prizeID int, seal func(models.WinnerRecord) (models.WinnerRecord, error)) (models.WinnerRecord, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -109,22 +195,357 @@ func (r *InMemoryRepository) SaveAward(_ context.Context, participantID, prizeID
 		return models.WinnerRecord{}, ErrPrizeUnavailable
 	}
 
-	person := r.people[personIdx]
-	prize := r.prizes[prizeIdx]
+	record, err := seal(models.WinnerRecord{
+		ID:        r.nextWinnerID,
+		Person:    r.people[personIdx],
+		Prize:     r.prizes[prizeIdx],
+		AwardedAt: time.Now().UTC().Truncate(time.Microsecond),
+	})
+	if err != nil {
+		return models.WinnerRecord{}, err
+	}
 
 	r.people = append(r.people[:personIdx], r.people[personIdx+1:]...)
 	r.prizes = append(r.prizes[:prizeIdx], r.prizes[prizeIdx+1:]...)
-
-	record := models.WinnerRecord{
-		ID:        r.nextWinnerID,
-		Person:    person,
-		Prize:     prize,
-		AwardedAt: time.Now().UTC(),
-	}
 	r.nextWinnerID++
 	r.winners = append([]models.WinnerRecord{record}, r.winners...)
-	if len(r.winners) > 5 {
-		r.winners = r.winners[:5]
-	}
 	return record, nil
 }
+
+func (r *InMemoryRepository) VoidAward(_ context.Context, winnerID int, reason, voidedBy string) (models.VoidedAward, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := -1
+	for i, w := range r.winners {
+		if w.ID == winnerID {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return models.VoidedAward{}, ErrAwardNotFound
+	}
+
+	voided := models.VoidedAward{
+		Winner:   r.winners[idx],
+		Reason:   reason,
+		VoidedBy: voidedBy,
+		VoidedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	r.winners = append(r.winners[:idx], r.winners[idx+1:]...)
+	r.people = append(r.people, voided.Winner.Person)
+	sort.Slice(r.people, func(i, j int) bool { return r.people[i].ID < r.people[j].ID })
+	r.prizes = append(r.prizes, voided.Winner.Prize)
+	sort.Slice(r.prizes, func(i, j int) bool { return r.prizes[i].ID < r.prizes[j].ID })
+	r.voided = append(r.voided, voided)
+	return voided, nil
+}
+
+func (r *InMemoryRepository) RevokeSession(_ context.Context, sessionID string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	for id, exp := range r.revokedSessions {
+		if exp.Before(now) {
+			delete(r.revokedSessions, id)
+		}
+	}
+	r.revokedSessions[sessionID] = expiresAt
+	return nil
+}
+
+func (r *InMemoryRepository) RevokeSessionsBefore(_ context.Context, cutoff time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cutoff.After(r.revokedBefore) {
+		r.revokedBefore = cutoff
+	}
+	return nil
+}
+
+func (r *InMemoryRepository) IsSessionRevoked(_ context.Context, sessionID string, issuedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.revokedSessions[sessionID]; ok {
+		return true, nil
+	}
+	return !issuedAt.After(r.revokedBefore), nil
+}
+
+func (r *InMemoryRepository) GetTOTP(_ context.Context, username string) (models.TOTPEnrollment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	enrollment, ok := r.totp[username]
+	if !ok {
+		return models.TOTPEnrollment{}, ErrTOTPNotEnrolled
+	}
+	return enrollment, nil
+}
+
+func (r *InMemoryRepository) SaveTOTP(_ context.Context, enrollment models.TOTPEnrollment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.totp[enrollment.Username] = enrollment
+	return nil
+}
+
+func (r *InMemoryRepository) DeleteTOTP(_ context.Context, username string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.totp, username)
+	delete(r.recoveryCodes, username)
+	return nil
+}
+
+func (r *InMemoryRepository) MarkTOTPStepUsed(_ context.Context, username string, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	enrollment, ok := r.totp[username]
+	if !ok {
+		return false, ErrTOTPNotEnrolled
+	}
+	if step <= enrollment.LastUsedStep {
+		return false, nil
+	}
+	enrollment.LastUsedStep = step
+	r.totp[username] = enrollment
+	return true, nil
+}
+
+func (r *InMemoryRepository) ReplaceRecoveryCodes(_ context.Context, username string, codeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	codes := make(map[string]bool, len(codeHashes))
+	for _, h := range codeHashes {
+		codes[h] = true
+	}
+	r.recoveryCodes[username] = codes
+	return nil
+}
+
+func (r *InMemoryRepository) ConsumeRecoveryCode(_ context.Context, username, codeHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.recoveryCodes[username][codeHash] {
+		return false, nil
+	}
+	delete(r.recoveryCodes[username], codeHash)
+	return true, nil
+}
+
+func (r *InMemoryRepository) UpsertParticipants(_ context.Context, people []models.Person) (int, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range people {
+		if p.Name == "" || p.Email == "" {
+			return 0, 0, ErrInvalidParticipant
+		}
+	}
+
+	created, updated := 0, 0
+	for _, p := range people {
+		idx := -1
+		for i, existing := range r.people {
+			if strings.EqualFold(existing.Email, p.Email) {
+				idx = i
+				break
+			}
+		}
+		if idx >= 0 {
+			r.people[idx].Name = p.Name
+			updated++
+			continue
+		}
+		if r.hasWinnerEmail(p.Email) {
+			updated++
+			continue
+		}
+		r.people = append(r.people, models.Person{ID: r.nextPersonID, Name: p.Name, Email: p.Email})
+		r.nextPersonID++
+		created++
+	}
+	return created, updated, nil
+}
+
+func (r *InMemoryRepository) hasWinnerEmail(email string) bool {
+	for _, w := range r.winners {
+		if strings.EqualFold(w.Person.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *InMemoryRepository) CreateAPIKey(_ context.Context, key models.APIKey) (models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key.ID = r.nextAPIKeyID
+	r.nextAPIKeyID++
+	r.apiKeys = append(r.apiKeys, key)
+	return key, nil
+}
+
+func (r *InMemoryRepository) ListAPIKeys(_ context.Context) ([]models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := make([]models.APIKey, len(r.apiKeys))
+	copy(keys, r.apiKeys)
+	return keys, nil
+}
+
+func (r *InMemoryRepository) RevokeAPIKey(_ context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.apiKeys {
+		if r.apiKeys[i].ID == id && r.apiKeys[i].RevokedAt == nil {
+			now := time.Now().UTC()
+			r.apiKeys[i].RevokedAt = &now
+			return nil
+		}
+	}
+	return ErrAPIKeyNotFound
+}
+
+func (r *InMemoryRepository) FindAPIKeyByHash(_ context.Context, hash string) (models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, k := range r.apiKeys {
+		if k.Hash == hash && k.RevokedAt == nil {
+			return k, nil
+		}
+	}
+	return models.APIKey{}, ErrAPIKeyNotFound
+}
+
+func (r *InMemoryRepository) TouchAPIKey(_ context.Context, id int, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.apiKeys {
+		if r.apiKeys[i].ID == id {
+			r.apiKeys[i].LastUsedAt = &usedAt
+			return nil
+		}
+	}
+	return ErrAPIKeyNotFound
+}
+
+func (r *InMemoryRepository) AppendAudit(_ context.Context, seal func(prevHash string) models.AuditEntry) (models.AuditEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	prevHash := ""
+	if n := len(r.audit); n > 0 {
+		prevHash = r.audit[n-1].Hash
+	}
+	entry := seal(prevHash)
+	entry.ID = int64(len(r.audit) + 1)
+	r.audit = append(r.audit, entry)
+	return entry, nil
+}
+
+func (r *InMemoryRepository) ListAudit(_ context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := []models.AuditEntry{}
+	for i := len(r.audit) - 1; i >= 0 && len(entries) < filter.Limit; i-- {
+		e := r.audit[i]
+		if filter.Actor != "" && e.Actor != filter.Actor ||
+			filter.Action != "" && e.Action != filter.Action ||
+			filter.Outcome != "" && e.Outcome != filter.Outcome ||
+			!filter.From.IsZero() && e.CreatedAt.Before(filter.From) ||
+			!filter.To.IsZero() && e.CreatedAt.After(filter.To) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func (r *InMemoryRepository) ListAuditChain(_ context.Context, afterID int64, limit int) ([]models.AuditEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if afterID >= int64(len(r.audit)) {
+		return nil, nil
+	}
+	end := min(int(afterID)+limit, len(r.audit))
+	entries := make([]models.AuditEntry, end-int(afterID))
+	copy(entries, r.audit[afterID:end])
+	return entries, nil
+}
+
+func (r *InMemoryRepository) CreateWebhook(_ context.Context, hook models.Webhook) (models.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hook.ID = r.nextWebhookID
+	r.nextWebhookID++
+	r.webhooks = append(r.webhooks, hook)
+	return hook, nil
+}
+
+func (r *InMemoryRepository) ListWebhooks(_ context.Context) ([]models.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hooks := []models.Webhook{}
+	for _, h := range r.webhooks {
+		if h.DeletedAt == nil {
+			hooks = append(hooks, h)
+		}
+	}
+	return hooks, nil
+}
+
+func (r *InMemoryRepository) DeleteWebhook(_ context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.webhooks {
+		if r.webhooks[i].ID == id && r.webhooks[i].DeletedAt == nil {
+			now := time.Now().UTC()
+			r.webhooks[i].DeletedAt = &now
+			return nil
+		}
+	}
+	return ErrWebhookNotFound
+}
+
+func (r *InMemoryRepository) AppendWebhookDelivery(_ context.Context, delivery models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delivery.ID = int64(len(r.webhookDeliveries) + 1)
+	r.webhookDeliveries = append(r.webhookDeliveries, delivery)
+	return nil
+}
+
+func (r *InMemoryRepository) ListWebhookDeliveries(_ context.Context, webhookID, limit int) ([]models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deliveries := []models.WebhookDelivery{}
+	for i := len(r.webhookDeliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if d := r.webhookDeliveries[i]; d.WebhookID == webhookID {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries, nil
+}
@@ -2,23 +2,107 @@ package repository
 
 import (
 	"context"
-	"errors"
+	"time"
 
+	"apiSorteos/internal/apperr"
 	"apiSorteos/internal/models"
 )
 
 var (
-	ErrNoParticipants       = errors.New("no hay personas disponibles")
-	ErrNoPrizes             = errors.New("no hay premios disponibles")
-	ErrParticipantUsed      = errors.New("el participante seleccionado ya no está disponible")
-	ErrPrizeUnavailable     = errors.New("el premio solicitado ya no está disponible")
-	ErrNothingToRegister    = errors.New("no se pudo registrar el premio porque faltan datos")
-	ErrRecentWinnersInvalid = errors.New("el límite de ganadores recientes no es válido")
+	ErrNoParticipants       = apperr.New("NO_PARTICIPANTS", "no hay personas disponibles")
+	ErrNoPrizes             = apperr.New("NO_PRIZES", "no hay premios disponibles")
+	ErrParticipantUsed      = apperr.New("PARTICIPANT_USED", "el participante seleccionado ya no está disponible")
+	ErrPrizeUnavailable     = apperr.New("PRIZE_UNAVAILABLE", "el premio solicitado ya no está disponible")
+	ErrNothingToRegister    = apperr.New("NOTHING_TO_REGISTER", "no se pudo registrar el premio porque faltan datos")
+	ErrRecentWinnersInvalid = apperr.New("INVALID_RECENT_WINNERS_LIMIT", "el límite de ganadores recientes no es válido")
+	ErrTOTPNotEnrolled      = apperr.New("TOTP_NOT_ENROLLED", "el usuario no tiene doble factor configurado")
+	ErrAPIKeyNotFound       = apperr.New("API_KEY_NOT_FOUND", "la clave de API no existe o fue revocada")
+	ErrInvalidParticipant   = apperr.New("INVALID_PARTICIPANT", "cada participante necesita nombre y email")
+	ErrWebhookNotFound      = apperr.New("WEBHOOK_NOT_FOUND", "el webhook no existe o fue eliminado")
+	ErrAwardNotFound        = apperr.New("AWARD_NOT_FOUND", "el premio entregado no existe o ya fue anulado")
 )
 
 type Repository interface {
+	// Ping comprueba que el almacenamiento responde.
+	Ping(ctx context.Context) error
 	ListParticipants(ctx context.Context) ([]models.Person, error)
+	// SearchParticipants devuelve una página ordenada por id y el total que
+	// cumple el filtro, sin contar Offset ni Limit.
+	SearchParticipants(ctx context.Context, filter models.ParticipantFilter) ([]models.Person, int, error)
+	// GetParticipant devuelve a la persona solo si sigue en espera; si ya ganó
+	// o no existe responde ErrParticipantUsed.
+	GetParticipant(ctx context.Context, id int) (models.Person, error)
 	ListPrizes(ctx context.Context) ([]models.Prize, error)
 	ListRecentWinners(ctx context.Context, limit int) ([]models.WinnerRecord, error)
-	SaveAward(ctx context.Context, participantID, prizeID int) (models.WinnerRecord, error)
+	// GetWinner devuelve un premio vigente; si no existe o fue anulado
+	// responde ErrAwardNotFound.
+	GetWinner(ctx context.Context, id int) (models.WinnerRecord, error)
+	// SaveAward registra el premio; seal recibe el registro completo antes de
+	// guardarlo para añadir datos derivados, como la firma del resultado.
+	SaveAward(ctx context.Context, participantID, prizeID int, seal func(models.WinnerRecord) (models.WinnerRecord, error)) (models.WinnerRecord, error)
+	// VoidAward deshace un premio: lo guarda en el historial de anulaciones y
+	// devuelve a la persona y al premio al sorteo.
+	VoidAward(ctx context.Context, winnerID int, reason, voidedBy string) (models.VoidedAward, error)
+	// UpsertParticipants crea o actualiza personas usando el email como clave.
+	UpsertParticipants(ctx context.Context, people []models.Person) (created, updated int, err error)
+}
+
+// SessionStore guarda la lista de sesiones revocadas. Una sesión queda
+// invalidada si su id fue revocado o si se emitió antes del último corte global.
+type SessionStore interface {
+	RevokeSession(ctx context.Context, sessionID string, expiresAt time.Time) error
+	RevokeSessionsBefore(ctx context.Context, cutoff time.Time) error
+	IsSessionRevoked(ctx context.Context, sessionID string, issuedAt time.Time) (bool, error)
+}
+
+// TOTPStore guarda el doble factor de cada administrador. Los códigos de
+// recuperación se almacenan solo como hash y se consumen una única vez.
+type TOTPStore interface {
+	GetTOTP(ctx context.Context, username string) (models.TOTPEnrollment, error)
+	SaveTOTP(ctx context.Context, enrollment models.TOTPEnrollment) error
+	DeleteTOTP(ctx context.Context, username string) error
+	MarkTOTPStepUsed(ctx context.Context, username string, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, username string, codeHashes []string) error
+	ConsumeRecoveryCode(ctx context.Context, username, codeHash string) (bool, error)
+}
+
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, key models.APIKey) (models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+	// FindAPIKeyByHash solo devuelve claves vigentes.
+	FindAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error)
+	TouchAPIKey(ctx context.Context, id int, usedAt time.Time) error
+}
+
+// AuditStore es la bitácora de solo inserción. AppendAudit debe leer el
+// último hash e insertar el registro de forma atómica; seal recibe ese hash y
+// devuelve el registro ya encadenado.
+type AuditStore interface {
+	AppendAudit(ctx context.Context, seal func(prevHash string) models.AuditEntry) (models.AuditEntry, error)
+	ListAudit(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+	// ListAuditChain devuelve registros en orden ascendente a partir de afterID.
+	ListAuditChain(ctx context.Context, afterID int64, limit int) ([]models.AuditEntry, error)
+}
+
+// WebhookStore guarda las suscripciones y el historial de envíos. Eliminar
+// una suscripción la desactiva sin borrar su historial.
+type WebhookStore interface {
+	CreateWebhook(ctx context.Context, hook models.Webhook) (models.Webhook, error)
+	// ListWebhooks solo devuelve suscripciones activas.
+	ListWebhooks(ctx context.Context) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int) error
+	AppendWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error
+	// ListWebhookDeliveries devuelve los envíos más recientes primero.
+	ListWebhookDeliveries(ctx context.Context, webhookID, limit int) ([]models.WebhookDelivery, error)
+}
+
+// Store agrupa todo lo que debe implementar un backend de persistencia.
+type Store interface {
+	Repository
+	SessionStore
+	TOTPStore
+	APIKeyStore
+	AuditStore
+	WebhookStore
 }
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"apiSorteos/internal/models"
@@ -20,6 +21,10 @@ func NewSQLServerRepository(db *sql.DB) *SQLServerRepository {
 	return &SQLServerRepository{db: db}
 }
 
+func (r *SQLServerRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
 func (r *SQLServerRepository) ListParticipants(ctx context.Context) ([]models.Person, error) {
 	query := `SELECT p.id, p.nombre, p.email
 FROM personas p
@@ -42,6 +47,58 @@ ORDER BY p.id`
 	return people, rows.Err()
 }
 
+func (r *SQLServerRepository) SearchParticipants(ctx context.Context, filter models.ParticipantFilter) ([]models.Person, int, error) {
+	where := []string{}
+	switch filter.Status {
+	case models.ParticipantsWaiting:
+		where = append(where, "NOT EXISTS (SELECT 1 FROM ganadores g WHERE g.persona_id = p.id)")
+	case models.ParticipantsAwarded:
+		where = append(where, "EXISTS (SELECT 1 FROM ganadores g WHERE g.persona_id = p.id)")
+	}
+	if filter.Query != "" {
+		where = append(where, "(p.nombre LIKE @p1 ESCAPE '\\' OR p.email LIKE @p1 ESCAPE '\\')")
+	}
+	clause := ""
+	if len(where) > 0 {
+		clause = "WHERE " + strings.Join(where, " AND ")
+	}
+	pattern := "%" + escapeLike(filter.Query) + "%"
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM personas p `+clause, pattern).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	// FETCH NEXT 0 ROWS es un error en SQL Server; sin límite solo se cuenta.
+	if filter.Limit <= 0 {
+		return []models.Person{}, total, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT p.id, p.nombre, p.email
+FROM personas p
+`+clause+`
+ORDER BY p.id
+OFFSET @p2 ROWS FETCH NEXT @p3 ROWS ONLY`, pattern, filter.Offset, filter.Limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	people := []models.Person{}
+	for rows.Next() {
+		var p models.Person
+		if err := rows.Scan(&p.ID, &p.Name, &p.Email); err != nil {
+			return nil, 0, err
+		}
+		people = append(people, p)
+	}
+	return people, total, rows.Err()
+}
+
+// escapeLike evita que los comodines de LIKE escritos por el usuario se interpreten.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`, "[", `\[`).Replace(s)
+}
+
 func (r *SQLServerRepository) ListPrizes(ctx context.Context) ([]models.Prize, error) {
 	query := `SELECT pr.id, pr.nombre, pr.descripcion
 FROM premios pr
@@ -69,7 +126,7 @@ func (r *SQLServerRepository) ListRecentWinners(ctx context.Context, limit int)
 		return nil, ErrRecentWinnersInvalid
 	}
 
-	query := `SELECT TOP(@p1) w.id, w.entregado_en, p.id, p.nombre, p.email, r.id, r.nombre, r.descripcion
+	query := `SELECT TOP(@p1) w.id, w.entregado_en, ISNULL(w.semilla, ''), ISNULL(w.firma, ''), p.id, p.nombre, p.email, r.id, r.nombre, r.descripcion
 FROM ganadores w
 INNER JOIN personas p ON p.id = w.persona_id
 INNER JOIN premios r ON r.id = w.premio_id
@@ -83,7 +140,7 @@ ORDER BY w.entregado_en DESC, w.id DESC`
 	var winners []models.WinnerRecord
 	for rows.Next() {
 		var rec models.WinnerRecord
-		if err := rows.Scan(&rec.ID, &rec.AwardedAt, &rec.Person.ID, &rec.Person.Name, &rec.Person.Email, &rec.Prize.ID, &rec.Prize.Name, &rec.Prize.Description); err != nil {
+		if err := rows.Scan(&rec.ID, &rec.AwardedAt, &rec.Seed, &rec.Signature, &rec.Person.ID, &rec.Person.Name, &rec.Person.Email, &rec.Prize.ID, &rec.Prize.Name, &rec.Prize.Description); err != nil {
 			return nil, err
 		}
 		winners = append(winners, rec)
@@ -91,7 +148,11 @@ ORDER BY w.entregado_en DESC, w.id DESC`
 	return winners, rows.Err()
 }
 
-func (r *SQLServerRepository) SaveAward(ctx context.Context, participantID, prizeID int) (models.WinnerRecord, error) {
+func (r *SQLServerRepository) GetWinner(ctx context.Context, id int) (models.WinnerRecord, error) {
+	return r.pickWinnerByID(ctx, r.db, id, false)
+}
+
+func (r *SQLServerRepository) SaveAward(ctx context.Context, participantID, prizeID int, seal func(models.WinnerRecord) (models.WinnerRecord, error)) (models.WinnerRecord, error) {
 	if participantID == 0 || prizeID == 0 {
 		return models.WinnerRecord{}, ErrNothingToRegister
 	}
@@ -112,7 +173,7 @@ func (r *SQLServerRepository) SaveAward(ctx context.Context, participantID, priz
 		return models.WinnerRecord{}, err
 	}
 
-	winner, err := r.insertWinner(ctx, tx, person, prize)
+	winner, err := r.insertWinner(ctx, tx, person, prize, seal)
 	if err != nil {
 		return models.WinnerRecord{}, err
 	}
@@ -123,8 +184,74 @@ func (r *SQLServerRepository) SaveAward(ctx context.Context, participantID, priz
 	return winner, nil
 }
 
-func (r *SQLServerRepository) pickPersonByID(ctx context.Context, tx *sql.Tx, participantID int) (models.Person, error) {
-	row := tx.QueryRowContext(ctx, `SELECT p.id, p.nombre, p.email
+// VoidAward mueve el registro de ganadores a premios_anulados; las consultas
+// de espera y premios disponibles solo miran ganadores, así que ambos vuelven al sorteo.
+func (r *SQLServerRepository) VoidAward(ctx context.Context, winnerID int, reason, voidedBy string) (models.VoidedAward, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.VoidedAward{}, err
+	}
+	defer tx.Rollback()
+
+	winner, err := r.pickWinnerByID(ctx, tx, winnerID, true)
+	if err != nil {
+		return models.VoidedAward{}, err
+	}
+
+	voided := models.VoidedAward{
+		Winner:   winner,
+		Reason:   reason,
+		VoidedBy: voidedBy,
+		VoidedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO premios_anulados (ganador_id, persona_id, premio_id, entregado_en, semilla, firma, motivo, anulado_por, anulado_en)
+VALUES (@p1, @p2, @p3, @p4, @p5, @p6, @p7, @p8, @p9)`,
+		winner.ID, winner.Person.ID, winner.Prize.ID, winner.AwardedAt, winner.Seed, winner.Signature, reason, voidedBy, voided.VoidedAt); err != nil {
+		return models.VoidedAward{}, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM ganadores WHERE id = @p1`, winner.ID); err != nil {
+		return models.VoidedAward{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.VoidedAward{}, err
+	}
+	return voided, nil
+}
+
+// pickWinnerByID lee un premio entregado; lock bloquea la fila hasta el fin
+// de la transacción.
+func (r *SQLServerRepository) pickWinnerByID(ctx context.Context, q rowQuerier, winnerID int, lock bool) (models.WinnerRecord, error) {
+	hint := ""
+	if lock {
+		hint = " WITH (UPDLOCK, ROWLOCK)"
+	}
+	row := q.QueryRowContext(ctx, `SELECT w.id, w.entregado_en, ISNULL(w.semilla, ''), ISNULL(w.firma, ''), p.id, p.nombre, p.email, r.id, r.nombre, r.descripcion
+FROM ganadores w`+hint+`
+INNER JOIN personas p ON p.id = w.persona_id
+INNER JOIN premios r ON r.id = w.premio_id
+WHERE w.id = @p1`, winnerID)
+	var rec models.WinnerRecord
+	if err := row.Scan(&rec.ID, &rec.AwardedAt, &rec.Seed, &rec.Signature, &rec.Person.ID, &rec.Person.Name, &rec.Person.Email, &rec.Prize.ID, &rec.Prize.Name, &rec.Prize.Description); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.WinnerRecord{}, ErrAwardNotFound
+		}
+		return models.WinnerRecord{}, err
+	}
+	return rec, nil
+}
+
+func (r *SQLServerRepository) GetParticipant(ctx context.Context, id int) (models.Person, error) {
+	return r.pickPersonByID(ctx, r.db, id)
+}
+
+// rowQuerier lo cumplen *sql.DB y *sql.Tx.
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func (r *SQLServerRepository) pickPersonByID(ctx context.Context, q rowQuerier, participantID int) (models.Person, error) {
+	row := q.QueryRowContext(ctx, `SELECT p.id, p.nombre, p.email
 FROM personas p
 WHERE p.id = @p1 AND NOT EXISTS (SELECT 1 FROM ganadores g WHERE g.persona_id = p.id)`, participantID)
 	var p models.Person
@@ -152,18 +279,421 @@ WHERE pr.id = @p1 AND NOT EXISTS (SELECT 1 FROM ganadores g WHERE g.premio_id =
 	return p, nil
 }
 
-func (r *SQLServerRepository) insertWinner(ctx context.Context, tx *sql.Tx, person models.Person, prize models.Prize) (models.WinnerRecord, error) {
-	awardedAt := time.Now().UTC()
-	row := tx.QueryRowContext(ctx, `INSERT INTO ganadores (persona_id, premio_id, entregado_en) OUTPUT INSERTED.id, INSERTED.entregado_en VALUES (@p1, @p2, @p3)`, person.ID, prize.ID, awardedAt)
+func (r *SQLServerRepository) insertWinner(ctx context.Context, tx *sql.Tx, person models.Person, prize models.Prize, seal func(models.WinnerRecord) (models.WinnerRecord, error)) (models.WinnerRecord, error) {
+	// Se trunca a microsegundos para que la fecha firmada sea la misma que se lee de vuelta.
+	record, err := seal(models.WinnerRecord{
+		Person:    person,
+		Prize:     prize,
+		AwardedAt: time.Now().UTC().Truncate(time.Microsecond),
+	})
+	if err != nil {
+		return models.WinnerRecord{}, err
+	}
+
+	row := tx.QueryRowContext(ctx, `INSERT INTO ganadores (persona_id, premio_id, entregado_en, semilla, firma) OUTPUT INSERTED.id VALUES (@p1, @p2, @p3, @p4, @p5)`,
+		person.ID, prize.ID, record.AwardedAt, record.Seed, record.Signature)
 	var winnerID int64
-	if err := row.Scan(&winnerID, &awardedAt); err != nil {
+	if err := row.Scan(&winnerID); err != nil {
 		return models.WinnerRecord{}, fmt.Errorf("no se pudo obtener el id del ganador: %w", err)
 	}
+	record.ID = int(winnerID)
+	return record, nil
+}
 
-	return models.WinnerRecord{
-		ID:        int(winnerID),
-		Person:    person,
-		Prize:     prize,
-		AwardedAt: awardedAt,
-	}, nil
+func (r *SQLServerRepository) RevokeSession(ctx context.Context, sessionID string, expiresAt time.Time) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM sesiones_revocadas WHERE expira_en < SYSUTCDATETIME()`); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, `IF NOT EXISTS (SELECT 1 FROM sesiones_revocadas WHERE id = @p1)
+INSERT INTO sesiones_revocadas (id, expira_en) VALUES (@p1, @p2)`, sessionID, expiresAt.UTC())
+	return err
+}
+
+func (r *SQLServerRepository) RevokeSessionsBefore(ctx context.Context, cutoff time.Time) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO revocaciones_globales (revocado_antes_de) VALUES (@p1)`, cutoff.UTC())
+	return err
+}
+
+func (r *SQLServerRepository) IsSessionRevoked(ctx context.Context, sessionID string, issuedAt time.Time) (bool, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT CASE
+	WHEN EXISTS (SELECT 1 FROM sesiones_revocadas WHERE id = @p1) THEN 1
+	WHEN EXISTS (SELECT 1 FROM revocaciones_globales WHERE revocado_antes_de >= @p2) THEN 1
+	ELSE 0 END`, sessionID, issuedAt.UTC())
+	var revoked bool
+	if err := row.Scan(&revoked); err != nil {
+		return false, err
+	}
+	return revoked, nil
+}
+
+func (r *SQLServerRepository) GetTOTP(ctx context.Context, username string) (models.TOTPEnrollment, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT usuario, secreto, confirmado, ultimo_paso, creado_en
+FROM admin_totp
+WHERE usuario = @p1`, username)
+	var e models.TOTPEnrollment
+	if err := row.Scan(&e.Username, &e.Secret, &e.Confirmed, &e.LastUsedStep, &e.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.TOTPEnrollment{}, ErrTOTPNotEnrolled
+		}
+		return models.TOTPEnrollment{}, err
+	}
+	return e, nil
+}
+
+func (r *SQLServerRepository) SaveTOTP(ctx context.Context, e models.TOTPEnrollment) error {
+	_, err := r.db.ExecContext(ctx, `MERGE admin_totp AS t
+USING (SELECT @p1 AS usuario) AS s ON t.usuario = s.usuario
+WHEN MATCHED THEN UPDATE SET secreto = @p2, confirmado = @p3, ultimo_paso = @p4
+WHEN NOT MATCHED THEN INSERT (usuario, secreto, confirmado, ultimo_paso, creado_en) VALUES (@p1, @p2, @p3, @p4, @p5);`,
+		e.Username, e.Secret, e.Confirmed, e.LastUsedStep, e.CreatedAt.UTC())
+	return err
+}
+
+func (r *SQLServerRepository) DeleteTOTP(ctx context.Context, username string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM admin_totp_recuperacion WHERE usuario = @p1`, username); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM admin_totp WHERE usuario = @p1`, username); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SQLServerRepository) MarkTOTPStepUsed(ctx context.Context, username string, step int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE admin_totp SET ultimo_paso = @p2 WHERE usuario = @p1 AND ultimo_paso < @p2`, username, step)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *SQLServerRepository) ReplaceRecoveryCodes(ctx context.Context, username string, codeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM admin_totp_recuperacion WHERE usuario = @p1`, username); err != nil {
+		return err
+	}
+	for _, h := range codeHashes {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO admin_totp_recuperacion (usuario, codigo_hash) VALUES (@p1, @p2)`, username, h); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *SQLServerRepository) ConsumeRecoveryCode(ctx context.Context, username, codeHash string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE admin_totp_recuperacion SET usado_en = SYSUTCDATETIME()
+WHERE usuario = @p1 AND codigo_hash = @p2 AND usado_en IS NULL`, username, codeHash)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *SQLServerRepository) UpsertParticipants(ctx context.Context, people []models.Person) (int, int, error) {
+	for _, p := range people {
+		if p.Name == "" || p.Email == "" {
+			return 0, 0, ErrInvalidParticipant
+		}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	created, updated := 0, 0
+	for _, p := range people {
+		var action string
+		row := tx.QueryRowContext(ctx, `MERGE personas AS t
+USING (SELECT @p1 AS nombre, @p2 AS email) AS s ON t.email = s.email
+WHEN MATCHED THEN UPDATE SET nombre = s.nombre
+WHEN NOT MATCHED THEN INSERT (nombre, email) VALUES (s.nombre, s.email)
+OUTPUT $action;`, p.Name, p.Email)
+		if err := row.Scan(&action); err != nil {
+			return 0, 0, err
+		}
+		if action == "INSERT" {
+			created++
+		} else {
+			updated++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+	return created, updated, nil
+}
+
+func (r *SQLServerRepository) CreateAPIKey(ctx context.Context, key models.APIKey) (models.APIKey, error) {
+	row := r.db.QueryRowContext(ctx, `INSERT INTO api_keys (nombre, prefijo, hash, alcances, creado_por, creado_en)
+OUTPUT INSERTED.id
+VALUES (@p1, @p2, @p3, @p4, @p5, @p6)`, key.Name, key.Prefix, key.Hash, strings.Join(key.Scopes, ","), key.CreatedBy, key.CreatedAt.UTC())
+	if err := row.Scan(&key.ID); err != nil {
+		return models.APIKey{}, err
+	}
+	return key, nil
+}
+
+func (r *SQLServerRepository) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, nombre, prefijo, hash, alcances, creado_por, creado_en, usado_en, revocado_en
+FROM api_keys
+ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (r *SQLServerRepository) RevokeAPIKey(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE api_keys SET revocado_en = SYSUTCDATETIME() WHERE id = @p1 AND revocado_en IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r *SQLServerRepository) FindAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT id, nombre, prefijo, hash, alcances, creado_por, creado_en, usado_en, revocado_en
+FROM api_keys
+WHERE hash = @p1 AND revocado_en IS NULL`, hash)
+	k, err := scanAPIKey(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.APIKey{}, ErrAPIKeyNotFound
+		}
+		return models.APIKey{}, err
+	}
+	return k, nil
+}
+
+func (r *SQLServerRepository) TouchAPIKey(ctx context.Context, id int, usedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE api_keys SET usado_en = @p2 WHERE id = @p1`, id, usedAt.UTC())
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIKey(row rowScanner) (models.APIKey, error) {
+	var (
+		k         models.APIKey
+		scopes    string
+		lastUsed  sql.NullTime
+		revokedAt sql.NullTime
+	)
+	if err := row.Scan(&k.ID, &k.Name, &k.Prefix, &k.Hash, &scopes, &k.CreatedBy, &k.CreatedAt, &lastUsed, &revokedAt); err != nil {
+		return models.APIKey{}, err
+	}
+	if scopes != "" {
+		k.Scopes = strings.Split(scopes, ",")
+	}
+	if lastUsed.Valid {
+		k.LastUsedAt = &lastUsed.Time
+	}
+	if revokedAt.Valid {
+		k.RevokedAt = &revokedAt.Time
+	}
+	return k, nil
+}
+
+func (r *SQLServerRepository) AppendAudit(ctx context.Context, seal func(prevHash string) models.AuditEntry) (models.AuditEntry, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.AuditEntry{}, err
+	}
+	defer tx.Rollback()
+
+	// TABLOCKX serializa las inserciones para que dos registros no compartan el mismo hash previo.
+	var prevHash string
+	err = tx.QueryRowContext(ctx, `SELECT TOP(1) hash FROM auditoria WITH (TABLOCKX, HOLDLOCK) ORDER BY id DESC`).Scan(&prevHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return models.AuditEntry{}, err
+	}
+
+	entry := seal(prevHash)
+	row := tx.QueryRowContext(ctx, `INSERT INTO auditoria (actor, accion, payload, ip, user_agent, resultado, estado, creado_en, hash_anterior, hash)
+OUTPUT INSERTED.id
+VALUES (@p1, @p2, @p3, @p4, @p5, @p6, @p7, @p8, @p9, @p10)`,
+		entry.Actor, entry.Action, string(entry.Payload), entry.IP, entry.UserAgent, entry.Outcome, entry.Status, entry.CreatedAt, entry.PrevHash, entry.Hash)
+	if err := row.Scan(&entry.ID); err != nil {
+		return models.AuditEntry{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.AuditEntry{}, err
+	}
+	return entry, nil
+}
+
+func (r *SQLServerRepository) ListAudit(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	query := `SELECT TOP(@p1) id, actor, accion, payload, ip, user_agent, resultado, estado, creado_en, hash_anterior, hash
+FROM auditoria
+WHERE (@p2 = '' OR actor = @p2)
+  AND (@p3 = '' OR accion = @p3)
+  AND (@p4 = '' OR resultado = @p4)
+  AND (@p5 IS NULL OR creado_en >= @p5)
+  AND (@p6 IS NULL OR creado_en <= @p6)
+ORDER BY id DESC`
+	rows, err := r.db.QueryContext(ctx, query, filter.Limit, filter.Actor, filter.Action, filter.Outcome, nullTime(filter.From), nullTime(filter.To))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanAuditEntries(rows)
+}
+
+func (r *SQLServerRepository) ListAuditChain(ctx context.Context, afterID int64, limit int) ([]models.AuditEntry, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT TOP(@p2) id, actor, accion, payload, ip, user_agent, resultado, estado, creado_en, hash_anterior, hash
+FROM auditoria
+WHERE id > @p1
+ORDER BY id`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanAuditEntries(rows)
+}
+
+func scanAuditEntries(rows *sql.Rows) ([]models.AuditEntry, error) {
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var (
+			e       models.AuditEntry
+			payload sql.NullString
+		)
+		if err := rows.Scan(&e.ID, &e.Actor, &e.Action, &payload, &e.IP, &e.UserAgent, &e.Outcome, &e.Status, &e.CreatedAt, &e.PrevHash, &e.Hash); err != nil {
+			return nil, err
+		}
+		if payload.Valid && payload.String != "" {
+			e.Payload = []byte(payload.String)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (r *SQLServerRepository) CreateWebhook(ctx context.Context, hook models.Webhook) (models.Webhook, error) {
+	row := r.db.QueryRowContext(ctx, `INSERT INTO webhooks (url, eventos, secreto, creado_por, creado_en)
+OUTPUT INSERTED.id
+VALUES (@p1, @p2, @p3, @p4, @p5)`, hook.URL, strings.Join(hook.Events, ","), hook.Secret, hook.CreatedBy, hook.CreatedAt.UTC())
+	if err := row.Scan(&hook.ID); err != nil {
+		return models.Webhook{}, err
+	}
+	return hook, nil
+}
+
+func (r *SQLServerRepository) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, url, eventos, secreto, creado_por, creado_en
+FROM webhooks
+WHERE eliminado_en IS NULL
+ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := []models.Webhook{}
+	for rows.Next() {
+		var (
+			h      models.Webhook
+			events string
+		)
+		if err := rows.Scan(&h.ID, &h.URL, &events, &h.Secret, &h.CreatedBy, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+		if events != "" {
+			h.Events = strings.Split(events, ",")
+		}
+		hooks = append(hooks, h)
+	}
+	return hooks, rows.Err()
+}
+
+func (r *SQLServerRepository) DeleteWebhook(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE webhooks SET eliminado_en = SYSUTCDATETIME() WHERE id = @p1 AND eliminado_en IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (r *SQLServerRepository) AppendWebhookDelivery(ctx context.Context, d models.WebhookDelivery) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO webhook_entregas (webhook_id, evento_id, tipo_evento, intento, estado_http, error, exitoso, duracion_ms, creado_en)
+VALUES (@p1, @p2, @p3, @p4, @p5, @p6, @p7, @p8, @p9)`,
+		d.WebhookID, int64(d.EventID), d.EventType, d.Attempt, d.StatusCode, d.Error, d.Success, d.DurationMs, d.CreatedAt.UTC())
+	return err
+}
+
+func (r *SQLServerRepository) ListWebhookDeliveries(ctx context.Context, webhookID, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT TOP(@p2) id, webhook_id, evento_id, tipo_evento, intento, estado_http, error, exitoso, duracion_ms, creado_en
+FROM webhook_entregas
+WHERE webhook_id = @p1
+ORDER BY id DESC`, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var (
+			d       models.WebhookDelivery
+			eventID int64
+		)
+		if err := rows.Scan(&d.ID, &d.WebhookID, &eventID, &d.EventType, &d.Attempt, &d.StatusCode, &d.Error, &d.Success, &d.DurationMs, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		d.EventID = uint64(eventID)
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t.UTC(), Valid: !t.IsZero()}
 }
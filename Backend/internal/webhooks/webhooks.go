@@ -0,0 +1,298 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"apiSorteos/internal/apperr"
+	"apiSorteos/internal/models"
+	"apiSorteos/internal/raffle"
+	"apiSorteos/internal/repository"
+)
+
+// Eventos que pueden suscribirse.
+const (
+	EventSpinComplete = "spin-complete"
+	EventAwardVoided  = "award-voided"
+	EventState        = "state"
+	EventStatePatch   = "state-patch"
+)
+
+var KnownEvents = []string{EventSpinComplete, EventAwardVoided, EventState, EventStatePatch}
+
+// Cabeceras de cada envío. La firma es HMAC-SHA256 con el secreto de la
+// suscripción sobre "<timestamp>.<cuerpo>", en hexadecimal.
+const (
+	HeaderEvent     = "X-Sorteos-Event"
+	HeaderEventID   = "X-Sorteos-Event-Id"
+	HeaderTimestamp = "X-Sorteos-Timestamp"
+	HeaderSignature = "X-Sorteos-Signature"
+)
+
+const (
+	maxAttempts    = 5
+	initialBackoff = 2 * time.Second
+	requestTimeout = 10 * time.Second
+	// maxConcurrent limita los envíos simultáneos para no saturar la red del evento.
+	maxConcurrent = 8
+
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+var (
+	ErrInvalidURL   = apperr.New("INVALID_WEBHOOK_URL", "la URL del webhook debe ser http o https")
+	ErrInvalidEvent = apperr.New("INVALID_WEBHOOK_EVENT", "tipo de evento de webhook desconocido")
+)
+
+type Dispatcher struct {
+	store   repository.WebhookStore
+	client  *http.Client
+	sem     chan struct{}
+	backoff time.Duration
+
+	// mu protege closed para que ningún envío empiece después de Shutdown.
+	mu       sync.Mutex
+	closed   bool
+	inflight sync.WaitGroup
+	done     chan struct{}
+}
+
+// Payload es el cuerpo JSON que recibe el sistema suscrito.
+type Payload struct {
+	ID     uint64      `json:"id"`
+	Type   string      `json:"type"`
+	SentAt time.Time   `json:"sentAt"`
+	Data   interface{} `json:"data"`
+}
+
+func NewDispatcher(store repository.WebhookStore) *Dispatcher {
+	return &Dispatcher{
+		store:   store,
+		client:  &http.Client{Timeout: requestTimeout},
+		sem:     make(chan struct{}, maxConcurrent),
+		backoff: initialBackoff,
+		done:    make(chan struct{}),
+	}
+}
+
+// Create devuelve el secreto de firma, que no vuelve a estar disponible.
+func (d *Dispatcher) Create(ctx context.Context, rawURL string, events []string, createdBy string) (models.Webhook, string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return models.Webhook{}, "", ErrInvalidURL
+	}
+	if len(events) == 0 {
+		return models.Webhook{}, "", ErrInvalidEvent
+	}
+	for _, evt := range events {
+		if !slices.Contains(KnownEvents, evt) {
+			return models.Webhook{}, "", ErrInvalidEvent
+		}
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return models.Webhook{}, "", err
+	}
+	secret := "whsec_" + base64.RawURLEncoding.EncodeToString(raw)
+
+	hook, err := d.store.CreateWebhook(ctx, models.Webhook{
+		URL:       u.String(),
+		Events:    slices.Clone(events),
+		Secret:    secret,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return models.Webhook{}, "", err
+	}
+	return hook, secret, nil
+}
+
+func (d *Dispatcher) List(ctx context.Context) ([]models.Webhook, error) {
+	return d.store.ListWebhooks(ctx)
+}
+
+func (d *Dispatcher) Delete(ctx context.Context, id int) error {
+	return d.store.DeleteWebhook(ctx, id)
+}
+
+func (d *Dispatcher) Deliveries(ctx context.Context, webhookID, limit int) ([]models.WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = defaultDeliveryLimit
+	}
+	return d.store.ListWebhookDeliveries(ctx, webhookID, min(limit, maxDeliveryLimit))
+}
+
+// Notify envía el evento a las suscripciones interesadas sin bloquear a quien
+// lo publica. Se registra con raffle.Service.OnPublish.
+func (d *Dispatcher) Notify(evt raffle.Event) {
+	if !slices.Contains(KnownEvents, evt.Type) {
+		return
+	}
+	body, err := json.Marshal(Payload{ID: evt.ID, Type: evt.Type, SentAt: time.Now().UTC(), Data: evt.Data})
+	if err != nil {
+		slog.Error("No se pudo serializar el evento para webhooks", "type", evt.Type, "error", err)
+		return
+	}
+
+	if !d.track() {
+		return
+	}
+	go func() {
+		defer d.inflight.Done()
+		hooks, err := d.store.ListWebhooks(context.Background())
+		if err != nil {
+			slog.Error("No se pudieron leer los webhooks", "error", err)
+			return
+		}
+		for _, hook := range hooks {
+			if slices.Contains(hook.Events, evt.Type) {
+				// Esta goroutine sigue contada, así que Shutdown aún no puede haber terminado.
+				d.inflight.Add(1)
+				go func() {
+					defer d.inflight.Done()
+					d.deliver(hook, evt, body)
+				}()
+			}
+		}
+	}()
+}
+
+// track cuenta un envío pendiente; devuelve false si ya se llamó a Shutdown.
+func (d *Dispatcher) track() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return false
+	}
+	d.inflight.Add(1)
+	return true
+}
+
+// Shutdown deja de aceptar eventos, corta las esperas entre reintentos y
+// aguarda a que terminen los envíos en curso o venza ctx.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.done)
+	}
+	d.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		d.inflight.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// deliver reintenta con espera exponencial mientras el error sea transitorio
+// y deja constancia de cada intento.
+func (d *Dispatcher) deliver(hook models.Webhook, evt raffle.Event, body []byte) {
+	backoff := d.backoff
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		d.sem <- struct{}{}
+		started := time.Now()
+		status, err := d.send(hook, evt, body)
+		<-d.sem
+
+		delivery := models.WebhookDelivery{
+			WebhookID:  hook.ID,
+			EventID:    evt.ID,
+			EventType:  evt.Type,
+			Attempt:    attempt,
+			StatusCode: status,
+			Success:    err == nil,
+			DurationMs: time.Since(started).Milliseconds(),
+			CreatedAt:  started.UTC(),
+		}
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+		if err := d.store.AppendWebhookDelivery(context.Background(), delivery); err != nil {
+			slog.Error("No se pudo registrar el envío del webhook", "webhookId", hook.ID, "error", err)
+		}
+
+		if err == nil || !retryable(status) {
+			return
+		}
+		if attempt < maxAttempts {
+			select {
+			case <-time.After(backoff):
+			case <-d.done:
+				slog.Warn("Webhook sin reintentar por apagado del servidor", "webhookId", hook.ID, "eventId", evt.ID, "attempt", attempt)
+				return
+			}
+			backoff *= 2
+		}
+	}
+	slog.Warn("Webhook agotó los reintentos", "webhookId", hook.ID, "eventId", evt.ID)
+}
+
+func (d *Dispatcher) send(hook models.Webhook, evt raffle.Event, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "apiSorteos-webhooks")
+	req.Header.Set(HeaderEvent, evt.Type)
+	req.Header.Set(HeaderEventID, strconv.FormatUint(evt.ID, 10))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(hook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("respuesta %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryable descarta reintentar errores 4xx, que no se arreglan solos,
+// salvo timeouts y límites de tasa.
+func retryable(status int) bool {
+	if status == http.StatusRequestTimeout || status == http.StatusTooManyRequests {
+		return true
+	}
+	return status < 400 || status >= 500
+}
+
+// Sign calcula la firma que el receptor debe comparar con X-Sorteos-Signature.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,276 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"apiSorteos/internal/raffle"
+	"apiSorteos/internal/repository"
+)
+
+// received guarda lo que llega al servidor de prueba.
+type received struct {
+	header http.Header
+	body   []byte
+	at     time.Time
+}
+
+type receiver struct {
+	mu       sync.Mutex
+	requests []received
+	statuses []int
+}
+
+// newReceiver responde con statuses en orden y con 200 cuando se agotan.
+func newReceiver(t *testing.T, statuses ...int) (*receiver, *httptest.Server) {
+	t.Helper()
+	rcv := &receiver{statuses: statuses}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rcv.mu.Lock()
+		n := len(rcv.requests)
+		rcv.requests = append(rcv.requests, received{header: r.Header.Clone(), body: body, at: time.Now()})
+		rcv.mu.Unlock()
+		status := http.StatusOK
+		if n < len(rcv.statuses) {
+			status = rcv.statuses[n]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return rcv, srv
+}
+
+func (r *receiver) all() []received {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]received(nil), r.requests...)
+}
+
+func newTestDispatcher(t *testing.T, url string) (*Dispatcher, *repository.InMemoryRepository, int, string) {
+	t.Helper()
+	repo := repository.NewInMemoryRepository()
+	d := NewDispatcher(repo)
+	d.backoff = 10 * time.Millisecond
+	hook, secret, err := d.Create(context.Background(), url, []string{EventSpinComplete}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, repo, hook.ID, secret
+}
+
+// eventually reintenta cond hasta que se cumpla o pasen cinco segundos.
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("la condición no se cumplió a tiempo")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// flush espera a que terminen los envíos en curso; después de Shutdown ya no
+// hay reintentos.
+func flush(t *testing.T, d *Dispatcher) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+}
+
+func TestDeliverySignature(t *testing.T) {
+	rcv, srv := newReceiver(t)
+	d, _, _, secret := newTestDispatcher(t, srv.URL)
+
+	d.Notify(raffle.Event{ID: 42, Type: EventSpinComplete, Data: map[string]int{"id": 7}})
+	flush(t, d)
+
+	reqs := rcv.all()
+	if len(reqs) != 1 {
+		t.Fatalf("se recibieron %d envíos, se esperaba 1", len(reqs))
+	}
+	req := reqs[0]
+	if got := req.header.Get(HeaderEvent); got != EventSpinComplete {
+		t.Errorf("%s = %q", HeaderEvent, got)
+	}
+	if got := req.header.Get(HeaderEventID); got != "42" {
+		t.Errorf("%s = %q", HeaderEventID, got)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(req.header.Get(HeaderTimestamp) + "."))
+	mac.Write(req.body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := req.header.Get(HeaderSignature); got != want {
+		t.Errorf("%s = %q, se esperaba %q", HeaderSignature, got, want)
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.ID != 42 || payload.Type != EventSpinComplete {
+		t.Errorf("payload = %+v", payload)
+	}
+}
+
+func TestDeliveryRetriesWithBackoff(t *testing.T) {
+	rcv, srv := newReceiver(t, http.StatusInternalServerError, http.StatusServiceUnavailable)
+	d, repo, hookID, _ := newTestDispatcher(t, srv.URL)
+
+	d.Notify(raffle.Event{ID: 1, Type: EventSpinComplete, Data: struct{}{}})
+	eventually(t, func() bool {
+		log, _ := repo.ListWebhookDeliveries(context.Background(), hookID, 10)
+		return len(log) == 3
+	})
+	flush(t, d)
+
+	reqs := rcv.all()
+	if len(reqs) != 3 {
+		t.Fatalf("se recibieron %d intentos, se esperaban 3", len(reqs))
+	}
+	if gap := reqs[1].at.Sub(reqs[0].at); gap < d.backoff {
+		t.Errorf("el segundo intento llegó tras %v, antes de %v", gap, d.backoff)
+	}
+	if gap := reqs[2].at.Sub(reqs[1].at); gap < 2*d.backoff {
+		t.Errorf("el tercer intento llegó tras %v, antes de %v", gap, 2*d.backoff)
+	}
+
+	log, err := repo.ListWebhookDeliveries(context.Background(), hookID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 3 {
+		t.Fatalf("la bitácora tiene %d intentos, se esperaban 3", len(log))
+	}
+	// ListWebhookDeliveries devuelve primero el más reciente.
+	wants := []struct {
+		attempt int
+		status  int
+		success bool
+	}{
+		{3, http.StatusOK, true},
+		{2, http.StatusServiceUnavailable, false},
+		{1, http.StatusInternalServerError, false},
+	}
+	for i, want := range wants {
+		got := log[i]
+		if got.Attempt != want.attempt || got.StatusCode != want.status || got.Success != want.success {
+			t.Errorf("intento %d = %+v, se esperaba %+v", i, got, want)
+		}
+		if got.EventID != 1 || got.EventType != EventSpinComplete {
+			t.Errorf("intento %d sin datos del evento: %+v", i, got)
+		}
+		if !want.success && got.Error == "" {
+			t.Errorf("intento %d fallido sin error registrado", i)
+		}
+	}
+}
+
+func TestDeliveryDoesNotRetryClientErrors(t *testing.T) {
+	rcv, srv := newReceiver(t, http.StatusBadRequest)
+	d, repo, hookID, _ := newTestDispatcher(t, srv.URL)
+
+	d.Notify(raffle.Event{ID: 1, Type: EventSpinComplete, Data: struct{}{}})
+	flush(t, d)
+
+	if n := len(rcv.all()); n != 1 {
+		t.Fatalf("se recibieron %d intentos, se esperaba 1", n)
+	}
+	log, _ := repo.ListWebhookDeliveries(context.Background(), hookID, 10)
+	if len(log) != 1 || log[0].Success {
+		t.Errorf("bitácora = %+v", log)
+	}
+}
+
+func TestNotifySkipsUnsubscribedEvents(t *testing.T) {
+	rcv, srv := newReceiver(t)
+	d, _, _, _ := newTestDispatcher(t, srv.URL)
+
+	d.Notify(raffle.Event{ID: 1, Type: EventStatePatch, Data: struct{}{}})
+	d.Notify(raffle.Event{ID: 2, Type: "paused", Data: struct{}{}})
+	flush(t, d)
+
+	if n := len(rcv.all()); n != 0 {
+		t.Errorf("se recibieron %d envíos, se esperaba ninguno", n)
+	}
+}
+
+func TestNotifyDeliversAwardVoided(t *testing.T) {
+	rcv, srv := newReceiver(t)
+	d := NewDispatcher(repository.NewInMemoryRepository())
+	if _, _, err := d.Create(context.Background(), srv.URL, []string{EventAwardVoided}, "admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	d.Notify(raffle.Event{ID: 1, Type: EventSpinComplete, Data: struct{}{}})
+	d.Notify(raffle.Event{ID: 2, Type: EventAwardVoided, Data: map[string]string{"reason": "duplicado"}})
+	flush(t, d)
+
+	reqs := rcv.all()
+	if len(reqs) != 1 || reqs[0].header.Get(HeaderEvent) != EventAwardVoided {
+		t.Fatalf("envíos = %+v, se esperaba solo award-voided", reqs)
+	}
+}
+
+func TestCreateRejectsUnknownEvents(t *testing.T) {
+	d := NewDispatcher(repository.NewInMemoryRepository())
+	for _, events := range [][]string{nil, {"spin-start"}, {EventSpinComplete, "paused"}} {
+		if _, _, err := d.Create(context.Background(), "https://intranet.example.com/hook", events, "admin"); !errors.Is(err, ErrInvalidEvent) {
+			t.Errorf("Create(%v) = %v, se esperaba %v", events, err, ErrInvalidEvent)
+		}
+	}
+	if _, _, err := d.Create(context.Background(), "ftp://intranet.example.com", []string{EventState}, "admin"); !errors.Is(err, ErrInvalidURL) {
+		t.Errorf("Create con ftp = %v, se esperaba %v", err, ErrInvalidURL)
+	}
+}
+
+func TestShutdownStopsRetries(t *testing.T) {
+	rcv, srv := newReceiver(t, http.StatusInternalServerError, http.StatusInternalServerError)
+	d, _, _, _ := newTestDispatcher(t, srv.URL)
+	d.backoff = time.Hour
+
+	d.Notify(raffle.Event{ID: 1, Type: EventSpinComplete, Data: struct{}{}})
+	eventually(t, func() bool { return len(rcv.all()) == 1 })
+	flush(t, d)
+
+	if n := len(rcv.all()); n != 1 {
+		t.Errorf("se recibieron %d intentos, se esperaba 1", n)
+	}
+	d.Notify(raffle.Event{ID: 2, Type: EventSpinComplete, Data: struct{}{}})
+	flush(t, d)
+	if n := len(rcv.all()); n != 1 {
+		t.Errorf("se envió un evento después de Shutdown")
+	}
+}
+
+func TestShutdownHonoursDeadline(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+	d, _, _, _ := newTestDispatcher(t, srv.URL)
+
+	d.Notify(raffle.Event{ID: 1, Type: EventSpinComplete, Data: struct{}{}})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := d.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown = %v, se esperaba %v", err, context.DeadlineExceeded)
+	}
+}
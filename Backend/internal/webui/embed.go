@@ -0,0 +1,25 @@
+//go:build embedui
+
+package webui
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// dist se llena con "npm run build:embed" en FrontEnd/sorteosFundasen.
+//
+//go:embed all:dist
+var dist embed.FS
+
+// Assets devuelve el build embebido, si el binario se compiló con -tags embedui.
+func Assets() (fs.FS, bool) {
+	assets, err := fs.Sub(dist, "dist")
+	if err != nil {
+		return nil, false
+	}
+	if _, err := fs.Stat(assets, indexFile); err != nil {
+		return nil, false
+	}
+	return assets, true
+}
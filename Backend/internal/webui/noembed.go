@@ -0,0 +1,10 @@
+//go:build !embedui
+
+package webui
+
+import "io/fs"
+
+// Assets devuelve el build embebido, si el binario se compiló con -tags embedui.
+func Assets() (fs.FS, bool) {
+	return nil, false
+}
@@ -0,0 +1,51 @@
+package webui
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const indexFile = "index.html"
+
+// Handler sirve la aplicación React. Vite pone un hash en los nombres de
+// assets/, así que esos archivos se cachean para siempre; index.html se
+// revalida en cada carga para que un binario nuevo tome efecto de inmediato.
+// Las rutas que no son archivos devuelven index.html (fallback de SPA).
+func Handler(assets fs.FS) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			return
+		}
+		name := strings.TrimPrefix(path.Clean(c.Request.URL.Path), "/")
+		// Las rutas desconocidas de la API deben seguir dando 404.
+		if name == "api" || strings.HasPrefix(name, "api/") {
+			return
+		}
+
+		if name != "" && name != indexFile {
+			if info, err := fs.Stat(assets, name); err == nil && !info.IsDir() {
+				if strings.HasPrefix(name, "assets/") {
+					c.Header("Cache-Control", "public, max-age=31536000, immutable")
+				} else {
+					c.Header("Cache-Control", "public, max-age=3600")
+				}
+				http.ServeFileFS(c.Writer, c.Request, assets, name)
+				return
+			}
+			if path.Ext(name) != "" {
+				return
+			}
+		}
+
+		index, err := fs.ReadFile(assets, indexFile)
+		if err != nil {
+			return
+		}
+		c.Header("Cache-Control", "no-cache")
+		c.Data(http.StatusOK, "text/html; charset=utf-8", index)
+	}
+}
@@ -2,9 +2,10 @@ import { useCallback, useEffect, useMemo, useRef, useState } from 'react'
 import './App.css'
 import Wheel from './components/Wheel.jsx'
 
-const API_BASE = import.meta.env.VITE_API_BASE || 'http://10.1.0.6:8080'
-const TWEENMAX_URL = 'https://cdnjs.cloudflare.com/ajax/libs/gsap/1.20.4/TweenMax.min.js'
-const WINWHEEL_URL = 'https://cdn.jsdelivr.net/npm/winwheeljs@2.7.0/dist/Winwheel.min.js'
+// Vacío en el build embebido: la API se sirve desde el mismo origen
+const API_BASE = import.meta.env.VITE_API_BASE ?? 'http://10.1.0.6:8080'
+const TWEENMAX_URL = import.meta.env.VITE_TWEENMAX_URL || 'https://cdnjs.cloudflare.com/ajax/libs/gsap/1.20.4/TweenMax.min.js'
+const WINWHEEL_URL = import.meta.env.VITE_WINWHEEL_URL || 'https://cdn.jsdelivr.net/npm/winwheeljs@2.7.0/dist/Winwheel.min.js'
 
 const COLLAPSE_COUNT = 18
 
@@ -13,7 +14,8 @@ const EMPTY_STATE = {
   remainingPrizes: 0,
   recentWinners: [],
   upcomingPrizes: [],
-  waitingPeople: []
+  waitingPeople: [],
+  version: 0
 }
 
 const FALLBACK_PRIZES = [
@@ -101,6 +103,8 @@ function App() {
   const [raffleState, setRaffleState] = useState(EMPTY_STATE)
   const [token, setToken] = useState('')
   const [password, setPassword] = useState('')
+  const [totpCode, setTotpCode] = useState('')
+  const [needsTotp, setNeedsTotp] = useState(false)
   const [error, setError] = useState('')
   const [loadingSpin, setLoadingSpin] = useState(false)
 
@@ -118,10 +122,16 @@ function App() {
   const [wheelReady, setWheelReady] = useState(false)
 
   const [showAllParticipants, setShowAllParticipants] = useState(false)
+  const [participantQuery, setParticipantQuery] = useState('')
+  const [participantResults, setParticipantResults] = useState(null)
+  // Lo que pide el control remoto del presentador (countdown / winners-board)
+  const [presenterView, setPresenterView] = useState(null)
   const [showAllPrizes, setShowAllPrizes] = useState(false)
 
   const stateRef = useRef(EMPTY_STATE)
+  const resyncStateRef = useRef(null)
   const eventSourceRef = useRef(null)
+  const lastEventIdRef = useRef('')
   const reconnectTimer = useRef(null)
 
   // Refs para que los handlers de SSE no dependan de closures (evita bugs aleatorios)
@@ -140,10 +150,15 @@ function App() {
   const waitingPeople = raffleState.waitingPeople || []
   const upcomingPrizes = raffleState.upcomingPrizes || []
 
-  const remainingPeopleCount = waitingPeople.length
+  // /api/state solo trae una muestra de quienes esperan; el total viene aparte
+  const remainingPeopleCount = raffleState.remainingPeople ?? waitingPeople.length
   const remainingPrizesCount = upcomingPrizes.length
 
-  const selectedParticipant = waitingPeople.find((p) => p.id === selectedParticipantId) || null
+  const participantOptions = participantResults ?? waitingPeople
+  const selectedParticipant =
+    participantOptions.find((p) => p.id === selectedParticipantId) ||
+    waitingPeople.find((p) => p.id === selectedParticipantId) ||
+    null
 
   const participantsToShow = showAllParticipants ? waitingPeople : waitingPeople.slice(0, COLLAPSE_COUNT)
   const prizesToShow = showAllPrizes ? upcomingPrizes : upcomingPrizes.slice(0, COLLAPSE_COUNT)
@@ -295,12 +310,40 @@ function App() {
     }
   }, [ensureScript, rebuildWheel])
 
+  // Búsqueda paginada en el backend: la lista completa ya no viaja en el estado
+  useEffect(() => {
+    const query = participantQuery.trim()
+    if (!query) {
+      setParticipantResults(null)
+      return undefined
+    }
+    const controller = new AbortController()
+    const timer = setTimeout(async () => {
+      try {
+        const params = new URLSearchParams({ status: 'waiting', q: query, pageSize: '50' })
+        const res = await fetch(`${API_BASE}/api/v1/participants?${params}`, { signal: controller.signal })
+        if (!res.ok) throw new Error('No se pudo buscar participantes')
+        const page = await res.json()
+        const items = Array.isArray(page.items) ? page.items : []
+        setParticipantResults(items)
+        if (items.length && !items.some((p) => p.id === selectedParticipantIdRef.current)) {
+          selectedParticipantIdRef.current = items[0].id
+          setSelectedParticipantId(items[0].id)
+        }
+      } catch (err) {
+        if (err?.name !== 'AbortError') setError(err?.message || 'Error buscando participantes')
+      }
+    }, 300)
+    return () => {
+      clearTimeout(timer)
+      controller.abort()
+    }
+  }, [participantQuery, raffleState.version])
+
   // -------------------------
   // SSE handlers
   // -------------------------
-  const handleStateEvent = useCallback((event) => {
-    const raw = JSON.parse(event.data || '{}') || {}
-    const payload = normalizeState(raw)
+  const applyState = useCallback((payload) => {
     stateRef.current = payload
     setRaffleState(payload)
     setConnectionLost(false)
@@ -309,16 +352,40 @@ function App() {
     if (!spinningRef.current) {
       const prizes = payload.upcomingPrizes?.length ? payload.upcomingPrizes : FALLBACK_PRIZES
       setWheelSegments(Array.isArray(prizes) ? prizes : FALLBACK_PRIZES)
-
-      // Auto-selección solo cuando no está girando
-      const currentId = selectedParticipantIdRef.current
-      if (payload.waitingPeople?.length && !payload.waitingPeople.some((p) => `${p.id}` === `${currentId}`)) {
-        selectedParticipantIdRef.current = payload.waitingPeople[0].id
-        setSelectedParticipantId(payload.waitingPeople[0].id)
-      }
+      // La auto-selección de participante la resuelve el efecto sobre participantOptions
     }
   }, [])
 
+  const handleStateEvent = useCallback((event) => {
+    applyState(normalizeState(JSON.parse(event.data || '{}') || {}))
+  }, [applyState])
+
+  const handleStatePatch = useCallback((event) => {
+    const patch = JSON.parse(event.data || '{}') || {}
+    const current = stateRef.current
+
+    // Si nos perdimos algún cambio el parche no aplica: pedimos el estado completo
+    if (current.version !== patch.baseVersion) {
+      resyncStateRef.current?.()
+      return
+    }
+
+    const removedPeople = new Set(patch.removedPeople || [])
+    const removedPrizes = new Set(patch.removedPrizes || [])
+    const added = Array.isArray(patch.addedWinners) ? patch.addedWinners : []
+    const addedIds = new Set(added.map((w) => w.id))
+
+    applyState(normalizeState({
+      ...current,
+      version: patch.version,
+      remainingPeople: patch.remainingPeople,
+      remainingPrizes: patch.remainingPrizes,
+      waitingPeople: current.waitingPeople.filter((p) => !removedPeople.has(p.id)),
+      upcomingPrizes: current.upcomingPrizes.filter((p) => !removedPrizes.has(p.id)),
+      recentWinners: [...added, ...current.recentWinners.filter((w) => !addedIds.has(w.id))].slice(0, 5),
+    }))
+  }, [applyState])
+
   const handleSpinStart = useCallback((event) => {
     const payload = JSON.parse(event.data || '{}')
 
@@ -370,17 +437,54 @@ function App() {
     launchConfetti()
   }, [])
 
+  const handlePresenter = useCallback((event) => {
+    const payload = JSON.parse(event.data || '{}') || {}
+    switch (payload.action) {
+      case 'idle':
+        setPresenterView(null)
+        setWinner(null)
+        setShowWheelModal(false)
+        break
+      case 'countdown':
+        setPresenterView({ action: 'countdown', seconds: payload.seconds || 5 })
+        break
+      case 'reveal':
+        setPresenterView(null)
+        if (payload.winner) setWinner(payload.winner)
+        break
+      case 'celebrate':
+        launchConfetti()
+        break
+      case 'winners-board':
+        setPresenterView({ action: 'winners-board', winners: payload.winners || [] })
+        break
+      default:
+        break
+    }
+  }, [])
+
   const setupEventStream = useCallback(() => {
     if (eventSourceRef.current) {
       eventSourceRef.current.close()
     }
 
-    const es = new EventSource(`${API_BASE}/events`)
+    // Al reconectar pedimos al backend los eventos que nos perdimos
+    const query = lastEventIdRef.current ? `?lastEventId=${encodeURIComponent(lastEventIdRef.current)}` : ''
+    const es = new EventSource(`${API_BASE}/api/v1/events${query}`)
     eventSourceRef.current = es
 
-    es.addEventListener('state', handleStateEvent)
-    es.addEventListener('spin-start', handleSpinStart)
-    es.addEventListener('spin-complete', handleSpinComplete)
+    const tracked = (handler) => (event) => {
+      if (event.lastEventId) lastEventIdRef.current = event.lastEventId
+      handler(event)
+    }
+
+    es.addEventListener('state', tracked(handleStateEvent))
+    es.addEventListener('state-patch', tracked(handleStatePatch))
+    es.addEventListener('spin-start', tracked(handleSpinStart))
+    es.addEventListener('spin-complete', tracked(handleSpinComplete))
+    es.addEventListener('presenter', tracked(handlePresenter))
+    // El backend se reinicia: EventSource reconectará solo cuando vuelva
+    es.addEventListener('server-restarting', () => setConnectionLost(true))
 
     es.onerror = () => {
       setConnectionLost(true)
@@ -394,14 +498,14 @@ function App() {
     }
 
     es.onopen = () => setConnectionLost(false)
-  }, [handleSpinComplete, handleSpinStart, handleStateEvent])
+  }, [handlePresenter, handleSpinComplete, handleSpinStart, handleStateEvent, handleStatePatch])
 
   // -------------------------
   // API
   // -------------------------
   const fetchInitialState = useCallback(async () => {
     try {
-      const res = await fetch(`${API_BASE}/api/state`)
+      const res = await fetch(`${API_BASE}/api/v1/state`)
       if (!res.ok) throw new Error('No se pudo cargar el estado inicial')
       const raw = await res.json()
       const data = normalizeState(raw)
@@ -418,19 +522,42 @@ function App() {
     }
   }, [rebuildWheel])
 
+  // Estado completo tras un state-patch con versión distinta a la nuestra
+  const resyncState = useCallback(async () => {
+    try {
+      const res = await fetch(`${API_BASE}/api/v1/state`)
+      if (!res.ok) throw new Error('No se pudo sincronizar el estado')
+      applyState(normalizeState(await res.json()))
+    } catch (err) {
+      setError(err?.message || 'Error sincronizando estado')
+    }
+  }, [applyState])
+
+  useEffect(() => {
+    resyncStateRef.current = resyncState
+  }, [resyncState])
+
   const handleLogin = async (e) => {
     e.preventDefault()
     setError('')
     try {
-      const res = await fetch(`${API_BASE}/api/auth/login`, {
+      const res = await fetch(`${API_BASE}/api/v1/auth/login`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
-        body: JSON.stringify({ password })
+        body: JSON.stringify({ password, code: totpCode })
       })
-      if (!res.ok) throw new Error('Contraseña incorrecta')
       const data = await res.json()
+      if (!res.ok) {
+        // Los errores llegan como application/problem+json con un code estable
+        if (data.code === 'TOTP_REQUIRED') {
+          setNeedsTotp(true)
+          throw new Error('Ingresa el código de tu app de autenticación')
+        }
+        throw new Error(needsTotp ? 'Contraseña o código incorrecto' : 'Contraseña incorrecta')
+      }
       setToken(data.token)
       setPassword('')
+      setTotpCode('')
     } catch (err) {
       setError(err?.message || 'Error de login')
     }
@@ -457,7 +584,7 @@ function App() {
 
     try {
       // Backend decide el premio y lo emite en SSE (spin-start)
-      const res = await fetch(`${API_BASE}/api/spin`, {
+      const res = await fetch(`${API_BASE}/api/v1/spin`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json', ...headers },
         body: JSON.stringify({ participantId: selectedParticipantId })
@@ -465,7 +592,7 @@ function App() {
 
       const data = await res.json()
       if (!res.ok) {
-        throw new Error(data.error || 'No se pudo registrar el giro')
+        throw new Error(data.detail || 'No se pudo registrar el giro')
       }
 
       // NO hacemos fetchInitialState aquí: el backend manda state por SSE
@@ -498,14 +625,14 @@ function App() {
   // Selección default de participante (pero no durante un giro)
   useEffect(() => {
     if (spinning) return
-    if (!waitingPeople.length) {
+    if (!participantOptions.length) {
       setSelectedParticipantId(null)
       return
     }
-    if (!waitingPeople.some((p) => `${p.id}` === `${selectedParticipantId}`)) {
-      setSelectedParticipantId(waitingPeople[0].id)
+    if (!participantOptions.some((p) => `${p.id}` === `${selectedParticipantId}`)) {
+      setSelectedParticipantId(participantOptions[0].id)
     }
-  }, [waitingPeople, selectedParticipantId, spinning])
+  }, [participantOptions, selectedParticipantId, spinning])
 
   // Mantener segmentos cuando no está girando
   useEffect(() => {
@@ -539,6 +666,19 @@ function App() {
     })
   }, [spinning, wheelReady, rebuildWheel, spinToPrize, wheelSegments])
 
+  // Cuenta regresiva pedida por el presentador
+  useEffect(() => {
+    if (presenterView?.action !== 'countdown') return
+    if (presenterView.seconds <= 0) {
+      setPresenterView(null)
+      return
+    }
+    const timer = setTimeout(() => {
+      setPresenterView((view) => (view?.action === 'countdown' ? { ...view, seconds: view.seconds - 1 } : view))
+    }, 1000)
+    return () => clearTimeout(timer)
+  }, [presenterView])
+
   // SSE
   useEffect(() => {
     setupEventStream()
@@ -588,6 +728,16 @@ function App() {
             />
             <button type="submit">Entrar</button>
           </div>
+          {needsTotp && (
+            <input
+              id="totp"
+              inputMode="numeric"
+              autoComplete="one-time-code"
+              placeholder="Código 2FA o de recuperación"
+              value={totpCode}
+              onChange={(e) => setTotpCode(e.target.value)}
+            />
+          )}
           <p className="helper">Solo el anfitrión puede lanzar la ruleta.</p>
         </form>
       </header>
@@ -606,13 +756,20 @@ function App() {
             <div className="control-stack">
               <label className="label" htmlFor="participant">Participante</label>
               <div className="select-row">
+                <input
+                  type="search"
+                  placeholder="Buscar por nombre o email"
+                  value={participantQuery}
+                  onChange={(e) => setParticipantQuery(e.target.value)}
+                  disabled={spinning || loadingSpin}
+                />
                 <select
                   id="participant"
                   value={selectedParticipantId || ''}
                   onChange={(e) => setSelectedParticipantId(Number(e.target.value))}
                   disabled={spinning || loadingSpin}
                 >
-                  {waitingPeople.map((person) => (
+                  {participantOptions.map((person) => (
                     <option key={person.id} value={person.id}>{person.name}</option>
                   ))}
                 </select>
@@ -775,6 +932,35 @@ function App() {
         </div>
       )}
 
+      {presenterView?.action === 'countdown' && (
+        <div className="modal-backdrop">
+          <div className="modal-card winner-card">
+            <p className="eyebrow">Preparados...</p>
+            <h2 className="winner-name">{presenterView.seconds}</h2>
+          </div>
+        </div>
+      )}
+
+      {presenterView?.action === 'winners-board' && (
+        <div className="modal-backdrop">
+          <div className="modal-card">
+            <p className="eyebrow">Ganadores</p>
+            <div className="history">
+              {presenterView.winners.length === 0 && <p className="muted">Aún no hay ganadores registrados</p>}
+              {presenterView.winners.map((item) => (
+                <div key={item.id} className="history-row">
+                  <div>
+                    <p className="strong">{item.person.name}</p>
+                    <p className="muted">{item.prize.name}</p>
+                  </div>
+                  <span className="time">{formatDate(item.awardedAt)}</span>
+                </div>
+              ))}
+            </div>
+          </div>
+        </div>
+      )}
+
       {error && <div className="error">{error}</div>}
     </div>
   )
@@ -1,7 +1,31 @@
+import { writeFileSync } from 'node:fs'
+import { join, resolve } from 'node:path'
 import { defineConfig } from 'vite'
 import react from '@vitejs/plugin-react'
 
+// keepGitkeep vuelve a crear el .gitkeep que vite borra al vaciar outDir: el
+// archivo está versionado para que el backend compile con -tags embedui
+// aunque nunca se haya hecho un build del frontend.
+function keepGitkeep() {
+  let outDir
+  return {
+    name: 'keep-gitkeep',
+    apply: 'build',
+    configResolved(config) {
+      outDir = resolve(config.root, config.build.outDir)
+    },
+    closeBundle() {
+      writeFileSync(join(outDir, '.gitkeep'), '')
+    },
+  }
+}
+
 // https://vite.dev/config/
-export default defineConfig({
-  plugins: [react()],
-})
+export default defineConfig(({ mode }) => ({
+  // "npm run build:embed" deja el build dentro del backend para compilarlo
+  // con -tags embedui y servir todo desde un solo ejecutable.
+  plugins: mode === 'embed' ? [react(), keepGitkeep()] : [react()],
+  build: mode === 'embed'
+    ? { outDir: '../../Backend/internal/webui/dist', emptyOutDir: true }
+    : {},
+}))